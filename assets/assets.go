@@ -0,0 +1,10 @@
+package assets
+
+import "embed"
+
+// HelmCharts contains the Helm charts bundled into the dra-deployer binary.
+// The "all:" prefix is required so files starting with '_' or '.' (such as
+// _helpers.tpl and .helmignore) are embedded as well.
+//
+//go:embed all:deployment/helm
+var HelmCharts embed.FS
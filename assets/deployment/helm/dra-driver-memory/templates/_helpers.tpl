@@ -48,6 +48,40 @@ app.kubernetes.io/name: {{ include "dra-driver-memory.name" . }}
 app.kubernetes.io/instance: {{ .Release.Name }}
 {{- end }}
 
+{{/*
+The container image reference: repository:tag, repository@digest or repository:tag@digest
+*/}}
+{{- define "dra-driver-memory.image" -}}
+{{- $image := .Values.image.repository }}
+{{- if .Values.image.tag }}
+{{- $image = printf "%s:%v" $image .Values.image.tag }}
+{{- end }}
+{{- if .Values.image.digest }}
+{{- $image = printf "%s@%s" $image .Values.image.digest }}
+{{- end }}
+{{- $image }}
+{{- end }}
+
+{{/*
+Create the name of the registry credentials secret
+*/}}
+{{- define "dra-driver-memory.registryAuthSecretName" -}}
+{{- printf "%s-registry-auth" (include "dra-driver-memory.fullname" .) }}
+{{- end }}
+
+{{/*
+The image pull secrets: the existing secrets followed by the registry credentials secret, if created
+*/}}
+{{- define "dra-driver-memory.imagePullSecrets" -}}
+{{- $secrets := .Values.imagePullSecrets | default list }}
+{{- if .Values.registryAuth.dockerConfigJSON }}
+{{- $secrets = append $secrets (dict "name" (include "dra-driver-memory.registryAuthSecretName" .)) }}
+{{- end }}
+{{- with $secrets }}
+{{- toYaml . }}
+{{- end }}
+{{- end }}
+
 {{/*
 Create the name of the service account to use
 */}}
@@ -73,3 +107,21 @@ Create the name of the cluster role binding to use
 {{- printf "%s-role-binding" (include "dra-driver-memory.fullname" .) }}
 {{- end }}
 
+{{/*
+The default DeviceClass name: driver.name for the default release, prefixed with the release
+name otherwise, so DeviceClasses, which are cluster-scoped, do not clash between releases
+*/}}
+{{- define "dra-driver-memory.deviceClassName" -}}
+{{- if eq .Release.Name (include "dra-driver-memory.name" .) }}
+{{- .Values.driver.name }}
+{{- else }}
+{{- printf "%s.%s" .Release.Name .Values.driver.name | trunc 253 | trimSuffix "." }}
+{{- end }}
+{{- end }}
+
+{{/*
+The preferred resource.k8s.io group version served by the cluster
+*/}}
+{{- define "dra-driver-memory.resourceAPIVersion" -}}
+{{- printf "resource.k8s.io/%s" (first .Values.resourceAPI.versions) }}
+{{- end }}
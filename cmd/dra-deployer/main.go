@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"os"
 
 	"k8s.io/klog/v2"
@@ -10,6 +11,13 @@ import (
 
 func main() {
 	if err := commands.Execute(); err != nil {
+		var exitErr *commands.ExitError
+		if errors.As(err, &exitErr) {
+			if exitErr.Err != nil {
+				klog.ErrorS(exitErr.Err, "Error executing command")
+			}
+			os.Exit(exitErr.Code)
+		}
 		klog.ErrorS(err, "Error executing command")
 		os.Exit(1)
 	}
@@ -10,6 +10,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
 
+	configv1 "github.com/openshift/api/config/v1"
 	securityv1 "github.com/openshift/api/security/v1"
 )
 
@@ -24,6 +25,9 @@ func init() {
 
 	// Register OpenShift security types
 	utilruntime.Must(securityv1.Install(scheme))
+
+	// Register OpenShift config types
+	utilruntime.Must(configv1.Install(scheme))
 }
 
 // New creates a new controller-runtime client with all necessary types registered
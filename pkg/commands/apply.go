@@ -2,19 +2,37 @@ package commands
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"time"
 
 	"github.com/spf13/cobra"
 	flag "github.com/spf13/pflag"
 
-	"github.com/k8stopologyawareschedwg/deployer/pkg/deployer/platform/detect"
-
 	cli "github.com/Tal-or/dra-deployer/pkg/client"
 	"github.com/Tal-or/dra-deployer/pkg/deploy"
-	"github.com/Tal-or/dra-deployer/pkg/params"
+	"github.com/Tal-or/dra-deployer/pkg/inventory"
+	"github.com/Tal-or/dra-deployer/pkg/values"
 )
 
 type applyArgs struct {
-	command string
+	command         string
+	forceConflicts  bool
+	prune           bool
+	wait            bool
+	timeout         time.Duration
+	dryRun          string
+	skipPreflight   bool
+	signaturePolicy string
+	digest          digestArgs
+	pullSecrets     pullSecretArgs
+	values          values.Options
+}
+
+type deleteArgs struct {
+	dryRun  string
+	wait    bool
+	timeout time.Duration
 }
 
 func NewApplyCommand(applyArgs *applyArgs) *cobra.Command {
@@ -23,24 +41,73 @@ func NewApplyCommand(applyArgs *applyArgs) *cobra.Command {
 		Short: "Apply DRA plugin manifests to a Kubernetes cluster",
 		Long: `Apply all DRA plugin manifests directly to a Kubernetes cluster. This will 
 		create or update the necessary resources including ServiceAccount, ClusterRole, 
-		ClusterRoleBinding, DaemonSet, DeviceClasses, and ValidatingAdmissionPolicy.`,
+		ClusterRoleBinding, DaemonSet, DeviceClasses, and ValidatingAdmissionPolicy.
+		Objects are applied using server-side apply with the "dra-deployer" field manager.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			dryRun, err := deploy.ParseDryRunStrategy(applyArgs.dryRun)
+			if err != nil {
+				return err
+			}
+
+			vals, err := applyArgs.values.MergeValues()
+			if err != nil {
+				return err
+			}
+
 			c, err := cli.New()
 			if err != nil {
 				return err
 			}
 
-			platform, err := detect.Platform(context.Background())
+			platform, err := resolvePlatform(context.Background())
 			if err != nil {
 				return err
 			}
 
-			return deploy.Deploy(context.Background(), c, params.EnvConfig{
-				Namespace:    namespace,
-				Image:        image,
-				Command:      applyArgs.command,
-				NodeSelector: nodeSelector,
-				Platform:     platform,
+			envConfig, err := newEnvConfig(applyArgs.command, vals, platform)
+			if err != nil {
+				return err
+			}
+
+			if err := setPullSecrets(&envConfig, applyArgs.pullSecrets); err != nil {
+				return err
+			}
+
+			// Only an image pinned by digest can be verified
+			digest := applyArgs.digest.withRegistryAuthFile(applyArgs.pullSecrets.registryAuthFile)
+			if applyArgs.signaturePolicy != "" {
+				digest.resolve = true
+			}
+			if err := resolveImageDigest(context.Background(), &envConfig, digest); err != nil {
+				return err
+			}
+
+			var signature *inventory.Signature
+			if applyArgs.signaturePolicy != "" {
+				signature, err = verifyImageSignature(context.Background(), envConfig, applyArgs.signaturePolicy, digest)
+				if err != nil {
+					return fmt.Errorf("refusing to deploy: %w", err)
+				}
+			}
+
+			if err := discoverResourceAPI(c, &envConfig); err != nil {
+				return err
+			}
+
+			if !applyArgs.skipPreflight {
+				// Report the checks on stderr, next to the apply logs
+				if err := runPreflight(context.Background(), c, envConfig, os.Stderr); err != nil {
+					return fmt.Errorf("%w, fix the failed checks or pass --skip-preflight", err)
+				}
+			}
+
+			return deploy.Deploy(context.Background(), c, envConfig, deploy.Options{
+				ForceConflicts: applyArgs.forceConflicts,
+				Wait:           applyArgs.wait,
+				Timeout:        applyArgs.timeout,
+				Prune:          applyArgs.prune,
+				DryRun:         dryRun,
+				Signature:      signature,
 			})
 		},
 	}
@@ -48,24 +115,73 @@ func NewApplyCommand(applyArgs *applyArgs) *cobra.Command {
 	return applyCmd
 }
 
-func NewDeleteCommand() *cobra.Command {
-	return &cobra.Command{
+func NewDeleteCommand(deleteArgs *deleteArgs) *cobra.Command {
+	deleteCmd := &cobra.Command{
 		Use:   "delete",
 		Short: "Delete DRA plugin manifests from a Kubernetes cluster",
-		Long: `Delete all DRA plugin manifests from a Kubernetes cluster. If a namespace 
-		is specified, deleting the namespace will automatically remove all namespaced resources 
-		(ServiceAccount, DaemonSet). Cluster-scoped resources will be deleted explicitly.`,
+		Long: `Delete all DRA plugin manifests from a Kubernetes cluster. Objects are deleted 
+		in the reverse install order. The namespace is only deleted if apply created it (it carries 
+		the app.kubernetes.io/managed-by=dra-deployer label), otherwise it is kept. 
+		The objects to delete are read from the inventory recorded by apply.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			dryRun, err := deploy.ParseDryRunStrategy(deleteArgs.dryRun)
+			if err != nil {
+				return err
+			}
+
+			platform, err := resolvePlatform(context.Background())
+			if err != nil {
+				return err
+			}
+
+			// The chart is only rendered for installs without inventory
+			envConfig, err := newEnvConfig("", nil, platform)
+			if err != nil {
+				return err
+			}
+
 			c, err := cli.New()
 			if err != nil {
 				return err
 			}
 
-			return deploy.Delete(context.Background(), c, namespace)
+			if err := discoverResourceAPI(c, &envConfig); err != nil {
+				return err
+			}
+
+			return deploy.Delete(context.Background(), c, envConfig, deploy.DeleteOptions{
+				DryRun:  dryRun,
+				Wait:    deleteArgs.wait,
+				Timeout: deleteArgs.timeout,
+			})
 		},
 	}
+	parseDeleteCmdFlags(deleteCmd.PersistentFlags(), deleteArgs)
+	return deleteCmd
 }
 
 func parseApplyCmdFlags(flags *flag.FlagSet, args *applyArgs) {
-	flags.StringVar(&args.command, "command", "", "Command pass for running the container")
+	flags.StringVar(&args.command, "command", "", "Command pass for running the container (defaults to the command of the selected driver)")
+	flags.BoolVar(&args.forceConflicts, "force-conflicts", false, "Take ownership of fields managed by other field managers when server-side apply reports conflicts")
+	flags.BoolVar(&args.prune, "prune", false, "Delete the objects of the previous install which are no longer rendered by the chart")
+	flags.BoolVar(&args.wait, "wait", false, "Wait until the kubelet plugin DaemonSet has rolled out on all its nodes")
+	flags.DurationVar(&args.timeout, "timeout", deploy.DefaultWaitTimeout, "Time to wait for the rollout when --wait is set")
+	flags.BoolVar(&args.skipPreflight, "skip-preflight", false, "Skip the preflight checks run before applying")
+	flags.StringVar(&args.signaturePolicy, "signature-policy", "", "Verify the image signatures against this containers-policy.json file and refuse to deploy if they do not satisfy it (implies --resolve-digest)")
+	parseDryRunFlag(flags, &args.dryRun)
+	parseDigestFlags(flags, &args.digest)
+	parsePullSecretFlags(flags, &args.pullSecrets)
+	parseValuesFlags(flags, &args.values)
+}
+
+func parseDeleteCmdFlags(flags *flag.FlagSet, args *deleteArgs) {
+	flags.BoolVar(&args.wait, "wait", false, "Wait until the namespace and the plugin pods are gone, then delete the ResourceSlices left behind by the driver")
+	flags.DurationVar(&args.timeout, "timeout", deploy.DefaultWaitTimeout, "Time to wait for the deletion when --wait is set")
+	parseDryRunFlag(flags, &args.dryRun)
+}
+
+// parseDryRunFlag registers the --dry-run flag; like kubectl, a bare --dry-run means client
+func parseDryRunFlag(flags *flag.FlagSet, dryRun *string) {
+	flags.StringVar(dryRun, "dry-run", string(deploy.DryRunNone), `Must be "none", "client", or "server". If client strategy, only report what would be changed without sending it, comparing the rendered fields with the live objects. If server strategy, submit server-side requests with dryRun=All so admission runs without persisting anything`)
+	flags.Lookup("dry-run").NoOptDefVal = string(deploy.DryRunClient)
 }
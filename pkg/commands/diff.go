@@ -0,0 +1,93 @@
+package commands
+
+import (
+	"context"
+	"os"
+
+	"github.com/spf13/cobra"
+	flag "github.com/spf13/pflag"
+
+	cli "github.com/Tal-or/dra-deployer/pkg/client"
+	"github.com/Tal-or/dra-deployer/pkg/deploy"
+	"github.com/Tal-or/dra-deployer/pkg/values"
+)
+
+const (
+	// diffExitChanged is the exit code of diff when differences were found
+	diffExitChanged = 1
+	// diffExitError is the exit code of diff when it failed, like kubectl diff
+	diffExitError = 2
+)
+
+type diffArgs struct {
+	command     string
+	pullSecrets pullSecretArgs
+	values      values.Options
+}
+
+func NewDiffCommand(diffArgs *diffArgs) *cobra.Command {
+	diffCmd := &cobra.Command{
+		Use:   "diff",
+		Short: "Diff the DRA plugin manifests against the live cluster state",
+		Long: `Diff the rendered DRA plugin manifests against the live cluster state. For every 
+object the result of a server-side dry-run apply is compared with the live object, ignoring 
+managed fields and status, and printed as a unified diff.
+
+Exit status: 0 no differences were found, 1 differences were found, >1 diff failed.`,
+		Example: `  # Show what applying a new image would change
+  dra-deployer diff -i quay.io/myorg/dra-driver:v1.0.1`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			changed, err := runDiff(diffArgs)
+			if err != nil {
+				return &ExitError{Code: diffExitError, Err: err}
+			}
+			if changed {
+				// Differences are not an error, only report them through the exit status
+				cmd.SilenceErrors = true
+				cmd.SilenceUsage = true
+				return &ExitError{Code: diffExitChanged}
+			}
+			return nil
+		},
+	}
+	parseDiffCmdFlags(diffCmd.PersistentFlags(), diffArgs)
+	return diffCmd
+}
+
+func runDiff(diffArgs *diffArgs) (bool, error) {
+	vals, err := diffArgs.values.MergeValues()
+	if err != nil {
+		return false, err
+	}
+
+	c, err := cli.New()
+	if err != nil {
+		return false, err
+	}
+
+	platform, err := resolvePlatform(context.Background())
+	if err != nil {
+		return false, err
+	}
+
+	envConfig, err := newEnvConfig(diffArgs.command, vals, platform)
+	if err != nil {
+		return false, err
+	}
+
+	if err := setPullSecrets(&envConfig, diffArgs.pullSecrets); err != nil {
+		return false, err
+	}
+
+	if err := discoverResourceAPI(c, &envConfig); err != nil {
+		return false, err
+	}
+
+	return deploy.Diff(context.Background(), c, envConfig, os.Stdout)
+}
+
+func parseDiffCmdFlags(flags *flag.FlagSet, args *diffArgs) {
+	flags.StringVar(&args.command, "command", "", "Command pass for running the container")
+	parsePullSecretFlags(flags, &args.pullSecrets)
+	parseValuesFlags(flags, &args.values)
+}
@@ -0,0 +1,120 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	flag "github.com/spf13/pflag"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	"github.com/Tal-or/dra-deployer/pkg/helm"
+	imgref "github.com/Tal-or/dra-deployer/pkg/image"
+	"github.com/Tal-or/dra-deployer/pkg/inventory"
+	"github.com/Tal-or/dra-deployer/pkg/params"
+)
+
+type digestArgs struct {
+	resolve   bool
+	tlsVerify bool
+	authFile  string
+}
+
+// resolveImageDigest pins the image of envConfig to the digest its tag points to in the registry,
+// so every node runs the same build even when the tag moves
+func resolveImageDigest(ctx context.Context, envConfig *params.EnvConfig, args digestArgs) error {
+	if !args.resolve {
+		return nil
+	}
+
+	ref, err := pluginImage(*envConfig)
+	if err != nil {
+		return err
+	}
+
+	// The digest is looked up in the mirror, ref keeps naming the source image which rendering
+	// points at the mirror
+	ref, err = imgref.ResolveDigest(ctx, ref, imgref.ResolveOptions{
+		AuthFile:  args.authFile,
+		TLSVerify: args.tlsVerify,
+		Mirrors:   envConfig.RegistryMirrors,
+	})
+	if err != nil {
+		return err
+	}
+
+	klog.InfoS("Pinned image by digest", "image", ref.String())
+	envConfig.Image = ref.String()
+	return nil
+}
+
+// verifyImageSignature checks the image of envConfig against the signature policy, pulling it from
+// its mirror like the nodes do, and returns the verification to record in the inventory. The image
+// must be pinned by digest.
+func verifyImageSignature(ctx context.Context, envConfig params.EnvConfig, policyPath string, args digestArgs) (*inventory.Signature, error) {
+	ref, err := pluginImage(envConfig)
+	if err != nil {
+		return nil, err
+	}
+	mirrored, rewritten, err := envConfig.RegistryMirrors.Rewrite(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	policyPath, err = filepath.Abs(policyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// The policy applies to the source image, which is pulled from its mirror
+	err = imgref.VerifySignature(ctx, ref, policyPath, imgref.ResolveOptions{
+		AuthFile:  args.authFile,
+		TLSVerify: args.tlsVerify,
+		Mirrors:   envConfig.RegistryMirrors,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	klog.InfoS("Verified image signature", "image", ref.String(), "policy", policyPath)
+	signature := &inventory.Signature{
+		Image:      ref.String(),
+		Policy:     policyPath,
+		VerifiedAt: metav1.Now(),
+	}
+	if rewritten {
+		signature.Mirror = mirrored.String()
+	}
+	return signature, nil
+}
+
+// withRegistryAuthFile returns args reading the registry credentials from authFile, the
+// --registry-auth-file of the pull secret, unless --authfile is set
+func (a digestArgs) withRegistryAuthFile(authFile string) digestArgs {
+	if a.authFile == "" {
+		a.authFile = authFile
+	}
+	return a
+}
+
+// pluginImage returns the plugin image envConfig renders: --image, or the image of the chart values
+func pluginImage(envConfig params.EnvConfig) (imgref.Reference, error) {
+	chartLoader, err := helm.NewChartLoaderForConfig(envConfig)
+	if err != nil {
+		return imgref.Reference{}, fmt.Errorf("failed to load Helm chart: %w", err)
+	}
+	ref, err := chartLoader.Image(envConfig)
+	if err != nil {
+		return imgref.Reference{}, fmt.Errorf("failed to parse image: %w", err)
+	}
+	return ref, nil
+}
+
+// parseDigestFlags registers the flags used to resolve the image digest
+func parseDigestFlags(flags *flag.FlagSet, args *digestArgs) {
+	flags.BoolVar(&args.resolve, "resolve-digest", false, "Look up the digest of the image tag in the registry and deploy the image pinned by digest")
+	flags.BoolVar(&args.tlsVerify, "tls-verify", true, "Require HTTPS and verify the registry certificates when resolving the image digest")
+	flags.StringVar(&args.authFile, "authfile", "", "Path of the registry credentials file used to resolve the image digest (defaults to --registry-auth-file, then the containers-auth.json locations, then ~/.docker/config.json)")
+}
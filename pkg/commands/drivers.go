@@ -0,0 +1,43 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Tal-or/dra-deployer/pkg/drivers"
+)
+
+func NewDriversCommand() *cobra.Command {
+	driversCmd := &cobra.Command{
+		Use:   "drivers",
+		Short: "Inspect the DRA drivers bundled with dra-deployer",
+	}
+	driversCmd.AddCommand(newDriversListCommand())
+	return driversCmd
+}
+
+func newDriversListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List the bundled DRA drivers",
+		Long: `List the DRA drivers bundled with dra-deployer. Select one with --driver; its image, 
+command and chart values are used unless overridden on the command line. The CHART column names 
+the chart a driver is deployed with: the cpu and example drivers reuse the dra-driver-memory chart 
+with their own values, so their objects carry the helm.sh/chart label of that chart.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tw := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+			fmt.Fprintln(tw, "NAME\tCHART\tIMAGE\tCOMMAND\tDESCRIPTION")
+			for _, d := range drivers.List() {
+				name := d.Name
+				if name == drivers.Default {
+					name += " (default)"
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", name, d.Chart(), d.Image, d.Command, d.Description)
+			}
+			return tw.Flush()
+		},
+	}
+}
@@ -0,0 +1,106 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"helm.sh/helm/v3/pkg/chartutil"
+
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/k8stopologyawareschedwg/deployer/pkg/deployer/platform"
+	"github.com/k8stopologyawareschedwg/deployer/pkg/deployer/platform/detect"
+
+	"github.com/Tal-or/dra-deployer/pkg/drivers"
+	imgref "github.com/Tal-or/dra-deployer/pkg/image"
+	"github.com/Tal-or/dra-deployer/pkg/params"
+	"github.com/Tal-or/dra-deployer/pkg/resourceapi"
+)
+
+// newEnvConfig builds the EnvConfig for the driver selected with --driver from the
+// global flags. The driver defaults (image, command and chart values) are layered below
+// userValues; --image and --command are only set when given, so they override both.
+func newEnvConfig(command string, userValues map[string]any, plat platform.Platform) (params.EnvConfig, error) {
+	driver, err := drivers.Get(driverName)
+	if err != nil {
+		return params.EnvConfig{}, err
+	}
+
+	driverValues, err := driver.DefaultValues()
+	if err != nil {
+		return params.EnvConfig{}, err
+	}
+
+	envConfig := params.EnvConfig{
+		Namespace:    namespace,
+		NodeSelector: nodeSelector,
+		Image:        image,
+		Command:      command,
+		Platform:     plat,
+		// MergeTables keeps null user values, so they can still remove chart defaults when rendering
+		Values:      chartutil.MergeTables(userValues, driverValues),
+		ChartDir:    driver.ChartDir,
+		ChartPath:   chartPath,
+		ReleaseName: releaseName,
+	}
+	envConfig.RegistryMirrors, err = parseRegistryMirrors()
+	if err != nil {
+		return params.EnvConfig{}, err
+	}
+
+	return envConfig, nil
+}
+
+// parseRegistryMirrors returns the mirrors set with --registry-mirror and --registries-conf
+func parseRegistryMirrors() (imgref.Mirrors, error) {
+	mirrors := imgref.Mirrors{RegistriesConf: registriesConf}
+	for _, value := range registryMirrors {
+		mirror, err := imgref.ParseMirror(value)
+		if err != nil {
+			return imgref.Mirrors{}, err
+		}
+		mirrors.Mirrors = append(mirrors.Mirrors, mirror)
+	}
+	return mirrors, nil
+}
+
+// discoverResourceAPI sets the resource.k8s.io versions of envConfig to the versions served by the cluster
+func discoverResourceAPI(cli client.Client, envConfig *params.EnvConfig) error {
+	versions, err := resourceapi.Discover(cli.RESTMapper())
+	if err != nil {
+		return err
+	}
+	if len(versions) == 0 {
+		klog.InfoS("The cluster serves no supported resource.k8s.io version, is DRA enabled?", "supported", resourceapi.Versions)
+		return nil
+	}
+
+	klog.V(2).InfoS("Discovered resource.k8s.io versions", "versions", versions)
+	envConfig.ResourceAPIVersions = versions
+	return nil
+}
+
+// resolvePlatform returns the platform set with --platform, detecting it from the cluster when the flag is unset
+func resolvePlatform(ctx context.Context) (platform.Platform, error) {
+	if platformName != "" {
+		return parsePlatformFlag()
+	}
+
+	plat, err := detect.Platform(ctx)
+	if err != nil {
+		return platform.Unknown, fmt.Errorf("failed to detect the cluster platform, set it with --platform: %w", err)
+	}
+	klog.V(2).InfoS("Detected platform", "platform", plat)
+	return plat, nil
+}
+
+// parsePlatformFlag returns the platform set with --platform
+func parsePlatformFlag() (platform.Platform, error) {
+	plat, ok := platform.ParsePlatform(platformName)
+	if !ok {
+		return platform.Unknown, fmt.Errorf("unknown platform %q, must be one of: kubernetes, openshift, hypershift", platformName)
+	}
+	return plat, nil
+}
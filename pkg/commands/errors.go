@@ -0,0 +1,21 @@
+package commands
+
+import "fmt"
+
+// ExitError makes the process exit with Code instead of the generic failure code.
+// Err, when set, is the error to report before exiting.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("exit status %d", e.Code)
+	}
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
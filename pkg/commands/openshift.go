@@ -0,0 +1,131 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	flag "github.com/spf13/pflag"
+
+	"github.com/k8stopologyawareschedwg/deployer/pkg/deployer/platform"
+
+	configv1 "github.com/openshift/api/config/v1"
+
+	cli "github.com/Tal-or/dra-deployer/pkg/client"
+	"github.com/Tal-or/dra-deployer/pkg/openshift"
+)
+
+type enableDRAArgs struct {
+	yes     bool
+	wait    bool
+	timeout time.Duration
+}
+
+func NewOpenShiftCommand() *cobra.Command {
+	openshiftCmd := &cobra.Command{
+		Use:   "openshift",
+		Short: "Prepare an OpenShift cluster for DRA drivers",
+	}
+	openshiftCmd.AddCommand(NewEnableDRACommand(&enableDRAArgs{}))
+	return openshiftCmd
+}
+
+func NewEnableDRACommand(enableDRAArgs *enableDRAArgs) *cobra.Command {
+	enableDRACmd := &cobra.Command{
+		Use:   "enable-dra",
+		Short: "Enable Dynamic Resource Allocation through the OpenShift FeatureGate",
+		Long: `Enable Dynamic Resource Allocation by setting the featureSet of the cluster FeatureGate 
+to TechPreviewNoUpgrade, or by adding DynamicResourceAllocation to the enabled gates of a CustomNoUpgrade 
+featureSet. The current featureSet is shown first; the FeatureGate is only changed when --yes is given, 
+since both featureSets cannot be reverted and prevent minor version upgrades.
+The Machine Config Operator then rolls a new MachineConfig out to every node; pass --wait to block 
+until all MachineConfigPools have finished rolling.`,
+		Example: `  # Show the current featureSet and what would change
+  dra-deployer openshift enable-dra
+
+  # Enable DRA and wait for the nodes to be updated
+  dra-deployer openshift enable-dra --yes --wait`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return enableDRA(enableDRAArgs)
+		},
+	}
+	parseEnableDRACmdFlags(enableDRACmd.PersistentFlags(), enableDRAArgs)
+	return enableDRACmd
+}
+
+func enableDRA(enableDRAArgs *enableDRAArgs) error {
+	ctx := context.Background()
+	out := os.Stdout
+
+	plat, err := resolvePlatform(ctx)
+	if err != nil {
+		return err
+	}
+	if plat != platform.OpenShift {
+		return fmt.Errorf("enable-dra requires an OpenShift cluster, got platform %q", plat)
+	}
+
+	c, err := cli.New()
+	if err != nil {
+		return err
+	}
+
+	fg, err := openshift.GetFeatureGate(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	featureSet := fg.Spec.FeatureSet
+	if featureSet == configv1.Default {
+		featureSet = "Default"
+	}
+	fmt.Fprintf(out, "Current featureSet: %s\n", featureSet)
+
+	if openshift.DRAEnabled(fg) {
+		fmt.Fprintf(out, "%s is already enabled, nothing to do\n", openshift.DRAFeatureGate)
+		return nil
+	}
+
+	switch fg.Spec.FeatureSet {
+	case configv1.CustomNoUpgrade:
+		fmt.Fprintf(out, "%s will be added to the enabled gates of featureSet %s\n", openshift.DRAFeatureGate, configv1.CustomNoUpgrade)
+	case configv1.Default:
+		fmt.Fprintf(out, "WARNING: setting featureSet %s cannot be undone and prevents minor version upgrades of the cluster.\n", configv1.TechPreviewNoUpgrade)
+	default:
+		return fmt.Errorf("enable-dra only changes the default and %s featureSets, enable %s in featureSet %s manually",
+			configv1.CustomNoUpgrade, openshift.DRAFeatureGate, featureSet)
+	}
+	fmt.Fprintln(out, "WARNING: the Machine Config Operator will roll a new MachineConfig out to every node, rebooting them one after another.")
+
+	if !enableDRAArgs.yes {
+		return errors.New("the FeatureGate was not changed, pass --yes to confirm")
+	}
+
+	var pools []openshift.MachineConfigPoolState
+	if enableDRAArgs.wait {
+		// Record the rendered MachineConfigs, so the wait can tell when the new ones are rolled out
+		pools, err = openshift.ListMachineConfigPools(ctx, c)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := openshift.EnableDRA(ctx, c, fg); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "FeatureGate %s updated, featureSet %s enables %s\n", openshift.FeatureGateName, fg.Spec.FeatureSet, openshift.DRAFeatureGate)
+
+	if !enableDRAArgs.wait {
+		return nil
+	}
+	return openshift.WaitForMachineConfigPools(ctx, c, pools, enableDRAArgs.timeout)
+}
+
+func parseEnableDRACmdFlags(flags *flag.FlagSet, args *enableDRAArgs) {
+	flags.BoolVar(&args.yes, "yes", false, "Confirm changing the FeatureGate, which cannot be reverted")
+	flags.BoolVar(&args.wait, "wait", false, "Wait until all MachineConfigPools have rolled out the new configuration")
+	flags.DurationVar(&args.timeout, "timeout", openshift.DefaultMachineConfigPoolTimeout, "Time to wait for the MachineConfigPools when --wait is set")
+}
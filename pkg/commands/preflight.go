@@ -0,0 +1,95 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+	flag "github.com/spf13/pflag"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	cli "github.com/Tal-or/dra-deployer/pkg/client"
+	"github.com/Tal-or/dra-deployer/pkg/params"
+	"github.com/Tal-or/dra-deployer/pkg/preflight"
+	"github.com/Tal-or/dra-deployer/pkg/values"
+)
+
+// errPreflightFailed is returned when at least one preflight check failed
+var errPreflightFailed = errors.New("preflight checks failed")
+
+type preflightArgs struct {
+	command string
+	values  values.Options
+}
+
+func NewPreflightCommand(preflightArgs *preflightArgs) *cobra.Command {
+	preflightCmd := &cobra.Command{
+		Use:   "preflight",
+		Short: "Check the cluster meets the requirements of the DRA plugin",
+		Long: `Check the cluster meets the requirements of the DRA plugin before deploying it: 
+the resource.k8s.io API group is served, ValidatingAdmissionPolicy is available, the caller is 
+allowed to create every rendered kind, the OpenShift FeatureGate enables DRA and some nodes match 
+the node selector. Each check reports pass, warn or fail; the command fails if any check failed.
+apply runs the same checks unless --skip-preflight is given.`,
+		Example: `  # Check the cluster before deploying to it
+  dra-deployer preflight
+
+  # Check the nodes selected for the plugin
+  dra-deployer preflight --node-selector node-role.kubernetes.io/worker=`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			vals, err := preflightArgs.values.MergeValues()
+			if err != nil {
+				return err
+			}
+
+			c, err := cli.New()
+			if err != nil {
+				return err
+			}
+
+			platform, err := resolvePlatform(context.Background())
+			if err != nil {
+				return err
+			}
+
+			envConfig, err := newEnvConfig(preflightArgs.command, vals, platform)
+			if err != nil {
+				return err
+			}
+
+			if err := discoverResourceAPI(c, &envConfig); err != nil {
+				return err
+			}
+
+			return runPreflight(context.Background(), c, envConfig, os.Stdout)
+		},
+	}
+	parsePreflightCmdFlags(preflightCmd.PersistentFlags(), preflightArgs)
+	return preflightCmd
+}
+
+func parsePreflightCmdFlags(flags *flag.FlagSet, args *preflightArgs) {
+	flags.StringVar(&args.command, "command", "", "Command pass for running the container (defaults to the command of the selected driver)")
+	parseValuesFlags(flags, &args.values)
+}
+
+// runPreflight runs the preflight checks, prints them to w and returns errPreflightFailed if any check failed
+func runPreflight(ctx context.Context, c client.Client, envConfig params.EnvConfig, w io.Writer) error {
+	checks, err := preflight.Run(ctx, c, envConfig)
+	if err != nil {
+		return fmt.Errorf("failed to run preflight checks: %w", err)
+	}
+
+	if err := preflight.Print(w, checks); err != nil {
+		return err
+	}
+
+	if preflight.Failed(checks) {
+		return errPreflightFailed
+	}
+	return nil
+}
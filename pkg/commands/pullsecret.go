@@ -0,0 +1,44 @@
+package commands
+
+import (
+	flag "github.com/spf13/pflag"
+
+	imgref "github.com/Tal-or/dra-deployer/pkg/image"
+	"github.com/Tal-or/dra-deployer/pkg/params"
+)
+
+type pullSecretArgs struct {
+	imagePullSecrets []string
+	registryAuthFile string
+}
+
+// setPullSecrets sets the image pull secrets of envConfig from the command line. Only the credentials
+// of the registry the nodes pull the plugin image from are read from the registry auth file.
+func setPullSecrets(envConfig *params.EnvConfig, args pullSecretArgs) error {
+	envConfig.ImagePullSecrets = args.imagePullSecrets
+	if args.registryAuthFile == "" {
+		return nil
+	}
+
+	ref, err := pluginImage(*envConfig)
+	if err != nil {
+		return err
+	}
+	ref, _, err = envConfig.RegistryMirrors.Rewrite(ref)
+	if err != nil {
+		return err
+	}
+
+	auth, err := imgref.ReadAuthFile(args.registryAuthFile, ref.Image)
+	if err != nil {
+		return err
+	}
+	envConfig.RegistryAuth = auth
+	return nil
+}
+
+// parsePullSecretFlags registers the flags used to pull the image from a private registry
+func parsePullSecretFlags(flags *flag.FlagSet, args *pullSecretArgs) {
+	flags.StringArrayVar(&args.imagePullSecrets, "image-pull-secret", []string{}, "Name of an existing secret in the install namespace used to pull the image (can specify multiple)")
+	flags.StringVar(&args.registryAuthFile, "registry-auth-file", "", "Registry auth file (e.g. ~/.docker/config.json) whose credentials for the image registry are stored in a kubernetes.io/dockerconfigjson Secret used to pull the image, also used to resolve the image digest unless --authfile is set")
+}
@@ -1,68 +1,175 @@
 package commands
 
 import (
+	"context"
 	"fmt"
+	"os"
 
 	"github.com/spf13/cobra"
+	flag "github.com/spf13/pflag"
 
 	"k8s.io/klog/v2"
 
-	"sigs.k8s.io/yaml"
+	"github.com/k8stopologyawareschedwg/deployer/pkg/deployer/platform"
 
 	"github.com/Tal-or/dra-deployer/pkg/helm"
-	"github.com/Tal-or/dra-deployer/pkg/params"
+	"github.com/Tal-or/dra-deployer/pkg/manifests"
+	"github.com/Tal-or/dra-deployer/pkg/resourceapi"
+	"github.com/Tal-or/dra-deployer/pkg/values"
 )
 
-func NewRenderCommand() *cobra.Command {
-	return &cobra.Command{
+type renderArgs struct {
+	command     string
+	kubeVersion string
+	outputDir   string
+	force       bool
+	output      string
+	showOnly    []string
+	digest      digestArgs
+	pullSecrets pullSecretArgs
+	values      values.Options
+}
+
+func NewRenderCommand(renderArgs *renderArgs) *cobra.Command {
+	renderCmd := &cobra.Command{
 		Use:   "render",
-		Short: "Render DRA plugin manifests to stdout",
+		Short: "Render DRA plugin manifests to stdout or a directory",
 		Long: `Render all DRA plugin manifests as YAML to stdout. This is useful for 
 reviewing the manifests before applying them or for piping to kubectl apply.`,
 		Example: `  # Render manifests with default namespace
   dra-deployer render
 
   # Render manifests with custom namespace
-  dra-deployer render --namespace my-namespace`,
+  dra-deployer render --namespace my-namespace
+
+  # Render manifests of another bundled driver
+  dra-deployer render --driver cpu
+
+  # Render manifests for a Kubernetes 1.33 cluster
+  dra-deployer render --kube-version 1.33
+
+  # Render manifests as a Kustomize base, one file per object
+  dra-deployer render --output-dir ./base
+
+  # Render the plugin DaemonSet as JSON
+  dra-deployer render --show-only templates/daemonset.yaml -o json
+
+  # Render manifests with the image pinned to the digest of its tag
+  dra-deployer render --image quay.io/myorg/dra-driver:latest --resolve-digest
+
+  # Render manifests pulling the image from a private registry
+  dra-deployer render --image registry.example.com/dra-driver:v1 --registry-auth-file ~/.docker/config.json
+
+  # Render manifests for a disconnected cluster pulling from a mirror registry
+  dra-deployer render --registry-mirror quay.io=mirror.example.com/quay
+
+  # Render the OpenShift manifests without cluster access
+  dra-deployer render --platform openshift
+
+  # Render manifests with custom chart values
+  dra-deployer render -f my-values.yaml --set daemonset.env.numDevices=16`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return render()
+			return render(renderArgs)
 		},
 	}
+	parseRenderCmdFlags(renderCmd.PersistentFlags(), renderArgs)
+	return renderCmd
 }
 
-// Render renders all manifests to stdout as YAML
-func render() error {
-	klog.InfoS("Rendering manifests", "namespace", namespace, "image", image)
+// Render renders all manifests to stdout in the requested output format, or to a directory
+func render(renderArgs *renderArgs) error {
+	if renderArgs.outputDir != "" && renderArgs.output != manifests.OutputYAML {
+		return fmt.Errorf("--output %s cannot be combined with --output-dir, which always writes YAML", renderArgs.output)
+	}
+
+	vals, err := renderArgs.values.MergeValues()
+	if err != nil {
+		return err
+	}
+
+	// render works offline, the platform is never detected
+	plat := platform.Kubernetes
+	if platformName != "" {
+		plat, err = parsePlatformFlag()
+		if err != nil {
+			return err
+		}
+	}
+
+	envConfig, err := newEnvConfig(renderArgs.command, vals, plat)
+	if err != nil {
+		return err
+	}
+
+	if err := setPullSecrets(&envConfig, renderArgs.pullSecrets); err != nil {
+		return err
+	}
+
+	// Only contacts the registry when --resolve-digest is set
+	if err := resolveImageDigest(context.Background(), &envConfig, renderArgs.digest.withRegistryAuthFile(renderArgs.pullSecrets.registryAuthFile)); err != nil {
+		return err
+	}
+
+	if renderArgs.kubeVersion != "" {
+		envConfig.ResourceAPIVersions, err = resourceapi.ForKubeVersion(renderArgs.kubeVersion)
+		if err != nil {
+			return err
+		}
+	}
 
 	// Load Helm chart
-	chartLoader, err := helm.NewChartLoader("")
+	chartLoader, err := helm.NewChartLoaderForConfig(envConfig)
 	if err != nil {
 		return fmt.Errorf("failed to load Helm chart: %w", err)
 	}
 
-	objects, err := chartLoader.Render(params.EnvConfig{
-		Namespace:    namespace,
-		Image:        image,
-		NodeSelector: nodeSelector,
-	})
+	img, err := chartLoader.Image(envConfig)
 	if err != nil {
-		return fmt.Errorf("failed to render Helm chart: %w", err)
+		return fmt.Errorf("failed to parse image: %w", err)
 	}
+	klog.InfoS("Rendering manifests", "driver", driverName, "platform", plat, "namespace", envConfig.Namespace, "image", img.String())
 
-	// Serialize each manifest as YAML
-	for i, obj := range objects {
-		if i > 0 {
-			fmt.Println("---")
-		}
+	objects, rewrites, err := chartLoader.RenderTemplates(envConfig, renderArgs.showOnly)
+	if err != nil {
+		return fmt.Errorf("failed to render Helm chart: %w", err)
+	}
+	for _, rewrite := range rewrites {
+		klog.InfoS("Rewrote image for registry mirror", "object", rewrite.Object, "container", rewrite.Container, "from", rewrite.From, "to", rewrite.To)
+	}
 
-		yamlData, err := yaml.Marshal(obj)
+	if renderArgs.outputDir != "" {
+		files, err := manifests.WriteDir(renderArgs.outputDir, objects, renderArgs.force)
 		if err != nil {
-			return fmt.Errorf("failed to marshal manifest to YAML: %w", err)
+			return err
 		}
+		klog.InfoS("Successfully rendered manifests", "outputDir", renderArgs.outputDir, "files", len(files))
+		return nil
+	}
 
-		fmt.Print(string(yamlData))
+	if err := manifests.Print(os.Stdout, objects, renderArgs.output); err != nil {
+		return err
 	}
 
 	klog.InfoS("Successfully rendered manifests")
 	return nil
 }
+
+func parseRenderCmdFlags(flags *flag.FlagSet, args *renderArgs) {
+	flags.StringVar(&args.command, "command", "", "Command pass for running the container (defaults to the command of the selected driver)")
+	flags.StringVar(&args.kubeVersion, "kube-version", "", "Kubernetes version of the target cluster (e.g. 1.33), selects the resource.k8s.io API versions to render (defaults to the newest supported version)")
+	flags.StringVar(&args.outputDir, "output-dir", "", "Write one file per object to this directory, cluster-scoped and namespaced objects in separate subdirectories, with a kustomization.yaml listing them")
+	flags.BoolVar(&args.force, "force", false, "Write into a non-empty --output-dir that was not written by render, overwriting files but removing none")
+	flags.StringVarP(&args.output, "output", "o", manifests.OutputYAML, "Output format, one of: yaml, json, json-list (a single v1 List object)")
+	flags.StringArrayVar(&args.showOnly, "show-only", []string{}, "Only show the objects rendered from the given templates, e.g. templates/daemonset.yaml (can specify multiple)")
+	parseDigestFlags(flags, &args.digest)
+	parsePullSecretFlags(flags, &args.pullSecrets)
+	parseValuesFlags(flags, &args.values)
+}
+
+// parseValuesFlags registers the Helm-style flags used to override chart values
+func parseValuesFlags(flags *flag.FlagSet, opts *values.Options) {
+	flags.StringSliceVarP(&opts.ValueFiles, "values", "f", []string{}, "Specify chart values in a YAML file (can specify multiple)")
+	flags.StringArrayVar(&opts.Values, "set", []string{}, "Set chart values on the command line (can specify multiple or separate values with commas: key1=val1,key2=val2)")
+	flags.StringArrayVar(&opts.StringValues, "set-string", []string{}, "Set STRING chart values on the command line (can specify multiple or separate values with commas: key1=val1,key2=val2)")
+	flags.StringArrayVar(&opts.FileValues, "set-file", []string{}, "Set chart values from respective files specified via the command line (can specify multiple or separate values with commas: key1=path1,key2=path2)")
+}
@@ -3,9 +3,12 @@ package commands
 import (
 	"flag"
 	"fmt"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
+
+	"github.com/Tal-or/dra-deployer/pkg/drivers"
 )
 
 var (
@@ -13,10 +16,16 @@ var (
 	verbosity    int
 	image        string
 	nodeSelector map[string]string
+	chartPath    string
+	driverName   string
+	platformName string
+	releaseName  string
+
+	registryMirrors []string
+	registriesConf  string
 )
 
 const (
-	defaultImage     = "quay.io/titzhak/dra-example-driver:v0.1.0"
 	defaultNamespace = "dra-deployer"
 	defaultVerbosity = 2
 )
@@ -34,9 +43,14 @@ func NewRootCommand() *cobra.Command {
 
 	parseFlags(rootCmd.PersistentFlags())
 
-	rootCmd.AddCommand(NewRenderCommand())
+	rootCmd.AddCommand(NewRenderCommand(&renderArgs{}))
 	rootCmd.AddCommand(NewApplyCommand(&applyArgs{}))
-	rootCmd.AddCommand(NewDeleteCommand())
+	rootCmd.AddCommand(NewDeleteCommand(&deleteArgs{}))
+	rootCmd.AddCommand(NewStatusCommand(&statusArgs{}))
+	rootCmd.AddCommand(NewDiffCommand(&diffArgs{}))
+	rootCmd.AddCommand(NewDriversCommand())
+	rootCmd.AddCommand(NewPreflightCommand(&preflightArgs{}))
+	rootCmd.AddCommand(NewOpenShiftCommand())
 	return rootCmd
 }
 
@@ -48,6 +62,12 @@ func Execute() error {
 func parseFlags(flags *pflag.FlagSet) {
 	flags.IntVarP(&verbosity, "verbose", "v", defaultVerbosity, "Log level verbosity")
 	flags.StringVarP(&namespace, "namespace", "n", defaultNamespace, "Namespace for namespaced resources")
-	flags.StringVarP(&image, "image", "i", defaultImage, "Container image for the DRA plugin")
+	flags.StringVar(&driverName, "driver", drivers.Default, fmt.Sprintf("Bundled DRA driver to deploy, one of: %s", strings.Join(drivers.Names(), ", ")))
+	flags.StringVarP(&image, "image", "i", "", "Container image for the DRA plugin (defaults to the image of the selected driver)")
 	flags.StringToStringVarP(&nodeSelector, "node-selector", "s", map[string]string{}, "Node selector for daemonset pods")
+	flags.StringVar(&platformName, "platform", "", "Platform of the cluster, one of: kubernetes, openshift, hypershift (auto-detected when unset, render assumes kubernetes); overrides the openshift.enabled chart value")
+	flags.StringVar(&releaseName, "release-name", "", "Name of the install, distinct names allow several installs in one cluster (defaults to the chart name)")
+	flags.StringArrayVar(&registryMirrors, "registry-mirror", []string{}, "Rewrite the images under a registry or repository to a mirror, as src=dst (can specify multiple)")
+	flags.StringVar(&registriesConf, "registries-conf", "", "Path of a registries.conf file whose mirrors rewrite the images not matched by --registry-mirror")
+	flags.StringVar(&chartPath, "chart", "", "Path to a Helm chart directory to use instead of the chart embedded in the binary")
 }
@@ -0,0 +1,94 @@
+package commands
+
+import (
+	"context"
+	"os"
+
+	"github.com/spf13/cobra"
+	flag "github.com/spf13/pflag"
+
+	cli "github.com/Tal-or/dra-deployer/pkg/client"
+	"github.com/Tal-or/dra-deployer/pkg/status"
+	"github.com/Tal-or/dra-deployer/pkg/values"
+)
+
+type statusArgs struct {
+	output      string
+	command     string
+	digest      digestArgs
+	pullSecrets pullSecretArgs
+	values      values.Options
+}
+
+func NewStatusCommand(statusArgs *statusArgs) *cobra.Command {
+	statusCmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show the status of a DRA plugin installation",
+		Long: `Show the status of a DRA plugin installation: every rendered object with whether 
+it exists and is up to date, the DaemonSet rollout state, the plugin pod running on each node 
+with its phase, restart count and running image, the ResourceSlices published by the driver and
+the image signature verification recorded by apply --signature-policy.
+Pass the same flags used for apply so the rendered objects can be compared with the live ones, 
+including --resolve-digest when apply pinned the image by digest (as --signature-policy does).`,
+		Example: `  # Show the status of the installation in the default namespace
+  dra-deployer status
+
+  # Show the status as JSON
+  dra-deployer status -n my-namespace -o json
+
+  # Show the status of an installation applied with the image pinned by digest
+  dra-deployer status --image quay.io/myorg/dra-driver:latest --resolve-digest`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			vals, err := statusArgs.values.MergeValues()
+			if err != nil {
+				return err
+			}
+
+			c, err := cli.New()
+			if err != nil {
+				return err
+			}
+
+			platform, err := resolvePlatform(context.Background())
+			if err != nil {
+				return err
+			}
+
+			envConfig, err := newEnvConfig(statusArgs.command, vals, platform)
+			if err != nil {
+				return err
+			}
+
+			if err := setPullSecrets(&envConfig, statusArgs.pullSecrets); err != nil {
+				return err
+			}
+
+			// Render the image apply deployed, pinned by digest when apply resolved it
+			digest := statusArgs.digest.withRegistryAuthFile(statusArgs.pullSecrets.registryAuthFile)
+			if err := resolveImageDigest(context.Background(), &envConfig, digest); err != nil {
+				return err
+			}
+
+			if err := discoverResourceAPI(c, &envConfig); err != nil {
+				return err
+			}
+
+			st, err := status.Get(context.Background(), c, envConfig)
+			if err != nil {
+				return err
+			}
+
+			return status.Print(os.Stdout, st, statusArgs.output)
+		},
+	}
+	parseStatusCmdFlags(statusCmd.PersistentFlags(), statusArgs)
+	return statusCmd
+}
+
+func parseStatusCmdFlags(flags *flag.FlagSet, args *statusArgs) {
+	flags.StringVarP(&args.output, "output", "o", status.OutputTable, "Output format, one of: table, json, yaml")
+	flags.StringVar(&args.command, "command", "", "Command the container was deployed with")
+	parseDigestFlags(flags, &args.digest)
+	parsePullSecretFlags(flags, &args.pullSecrets)
+	parseValuesFlags(flags, &args.values)
+}
@@ -0,0 +1,115 @@
+package deploy
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/Tal-or/dra-deployer/pkg/resourceapi"
+)
+
+// pluginPods returns the pods of the DaemonSets among objects, so their termination can be waited for
+func pluginPods(ctx context.Context, cli client.Client, objects []*unstructured.Unstructured) ([]*unstructured.Unstructured, error) {
+	var pods []*unstructured.Unstructured
+	for _, obj := range objects {
+		if obj.GroupVersionKind().GroupKind() != (schema.GroupKind{Group: "apps", Kind: "DaemonSet"}) {
+			continue
+		}
+
+		ds := &appsv1.DaemonSet{}
+		if err := cli.Get(ctx, client.ObjectKeyFromObject(obj), ds); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to get DaemonSet %s: %w", obj.GetName(), err)
+		}
+
+		dsPods, err := ListDaemonSetPods(ctx, cli, ds)
+		if err != nil {
+			return nil, err
+		}
+		for _, pod := range dsPods {
+			podObj := &unstructured.Unstructured{}
+			podObj.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Pod"))
+			podObj.SetNamespace(pod.Namespace)
+			podObj.SetName(pod.Name)
+			pods = append(pods, podObj)
+		}
+	}
+	return pods, nil
+}
+
+// deleteResourceSlices deletes the ResourceSlices published by driverName. The kubelet plugin
+// removes its slices when it shuts down cleanly, this catches the ones left behind.
+func deleteResourceSlices(ctx context.Context, cli client.Client, driverName string, versions []string, dryRun DryRunStrategy) error {
+	slices, err := listResources(ctx, cli, "ResourceSliceList", versions, client.MatchingFields{"spec.driver": driverName})
+	if err != nil {
+		return err
+	}
+	if len(slices) == 0 {
+		klog.V(2).InfoS("No ResourceSlices left behind", "driver", driverName)
+		return nil
+	}
+
+	klog.InfoS("Deleting orphaned ResourceSlices"+dryRun.suffix(), "driver", driverName, "count", len(slices))
+	for i := range slices {
+		if err := deleteObject(ctx, cli, &slices[i], dryRun); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// allocatedClaims returns the namespace/name of the ResourceClaims with devices allocated from driverName
+func allocatedClaims(ctx context.Context, cli client.Client, driverName string, versions []string) ([]string, error) {
+	claims, err := listResources(ctx, cli, "ResourceClaimList", versions)
+	if err != nil {
+		return nil, err
+	}
+
+	var allocated []string
+	for i := range claims {
+		if allocatedFrom(&claims[i], driverName) {
+			allocated = append(allocated, claims[i].GetNamespace()+"/"+claims[i].GetName())
+		}
+	}
+	return allocated, nil
+}
+
+// allocatedFrom returns true if any device allocated to claim comes from driverName
+func allocatedFrom(claim *unstructured.Unstructured, driverName string) bool {
+	results, _, _ := unstructured.NestedSlice(claim.Object, "status", "allocation", "devices", "results")
+	for _, r := range results {
+		if result, ok := r.(map[string]any); ok && result["driver"] == driverName {
+			return true
+		}
+	}
+	return false
+}
+
+// listResources lists resource.k8s.io objects with the preferred served version. A cluster not
+// serving resource.k8s.io has no such objects.
+func listResources(ctx context.Context, cli client.Client, listKind string, versions []string, opts ...client.ListOption) ([]unstructured.Unstructured, error) {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(schema.GroupVersionKind{
+		Group:   resourceapi.Group,
+		Version: resourceapi.Preferred(versions),
+		Kind:    listKind,
+	})
+	if err := cli.List(ctx, list, opts...); err != nil {
+		if meta.IsNoMatchError(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to list %s: %w", listKind, err)
+	}
+	return list.Items, nil
+}
@@ -0,0 +1,46 @@
+package deploy
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newClaim(drivers ...string) *unstructured.Unstructured {
+	claim := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "resource.k8s.io/v1",
+		"kind":       "ResourceClaim",
+		"metadata":   map[string]any{"namespace": "default", "name": "claim"},
+	}}
+	if len(drivers) == 0 {
+		return claim
+	}
+
+	results := make([]any, 0, len(drivers))
+	for _, driver := range drivers {
+		results = append(results, map[string]any{"driver": driver, "pool": "node-1", "device": "dev-0", "request": "req"})
+	}
+	_ = unstructured.SetNestedSlice(claim.Object, results, "status", "allocation", "devices", "results")
+	return claim
+}
+
+func TestAllocatedFrom(t *testing.T) {
+	tests := []struct {
+		name   string
+		claim  *unstructured.Unstructured
+		expect bool
+	}{
+		{name: "not allocated", claim: newClaim(), expect: false},
+		{name: "allocated from the driver", claim: newClaim("dra.memory"), expect: true},
+		{name: "allocated from another driver", claim: newClaim("gpu.example.com"), expect: false},
+		{name: "allocated from several drivers", claim: newClaim("gpu.example.com", "dra.memory"), expect: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := allocatedFrom(tt.claim, "dra.memory"); got != tt.expect {
+				t.Errorf("allocatedFrom() = %v, want %v", got, tt.expect)
+			}
+		})
+	}
+}
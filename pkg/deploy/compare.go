@@ -0,0 +1,98 @@
+package deploy
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// GetLive fetches the live state of obj from the cluster.
+// It returns nil without error when the object does not exist.
+func GetLive(ctx context.Context, cli client.Client, obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	live := &unstructured.Unstructured{}
+	live.SetGroupVersionKind(obj.GroupVersionKind())
+	err := cli.Get(ctx, client.ObjectKeyFromObject(obj), live)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get object %s: %w", objectKey(obj), err)
+	}
+	return live, nil
+}
+
+// DryRunApply returns obj as it would be stored by the API server after applying it with
+// server-side apply, without persisting anything. Conflicting fields are forced, so the
+// result reflects what the deployer would end up writing.
+func DryRunApply(ctx context.Context, cli client.Client, obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	applied := obj.DeepCopy()
+	err := cli.Apply(ctx, client.ApplyConfigurationFromUnstructured(applied),
+		client.FieldOwner(FieldManager), client.ForceOwnership, client.DryRunAll)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dry-run apply object %s: %w", objectKey(obj), err)
+	}
+	return applied, nil
+}
+
+// IsUpToDate reports whether applying obj would leave live unchanged
+func IsUpToDate(ctx context.Context, cli client.Client, obj, live *unstructured.Unstructured) (bool, error) {
+	applied, err := DryRunApply(ctx, cli, obj)
+	if err != nil {
+		return false, err
+	}
+	return equality.Semantic.DeepEqual(Normalize(live).Object, Normalize(applied).Object), nil
+}
+
+// Normalize returns a copy of obj without the fields that are maintained by the
+// API server or the controllers, like managed fields, resource version, UID and status
+func Normalize(obj *unstructured.Unstructured) *unstructured.Unstructured {
+	normalized := obj.DeepCopy()
+	unstructured.RemoveNestedField(normalized.Object, "metadata", "managedFields")
+	unstructured.RemoveNestedField(normalized.Object, "metadata", "resourceVersion")
+	unstructured.RemoveNestedField(normalized.Object, "metadata", "generation")
+	unstructured.RemoveNestedField(normalized.Object, "metadata", "uid")
+	unstructured.RemoveNestedField(normalized.Object, "metadata", "creationTimestamp")
+	unstructured.RemoveNestedField(normalized.Object, "status")
+	return normalized
+}
+
+// containsFields returns true if every field set in desired has the same value in live. Maps in
+// live may hold more fields, like the ones defaulted by the API server; lists must have the same
+// length and matching items.
+func containsFields(live, desired any) bool {
+	switch desired := desired.(type) {
+	case map[string]any:
+		liveMap, ok := live.(map[string]any)
+		if !ok {
+			return false
+		}
+		for key, value := range desired {
+			liveValue, found := liveMap[key]
+			if !found && value != nil {
+				return false
+			}
+			if found && !containsFields(liveValue, value) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		liveList, ok := live.([]any)
+		if !ok || len(liveList) != len(desired) {
+			return false
+		}
+		for i := range desired {
+			if !containsFields(liveList[i], desired[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return equality.Semantic.DeepEqual(live, desired)
+	}
+}
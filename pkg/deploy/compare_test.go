@@ -0,0 +1,52 @@
+package deploy
+
+import "testing"
+
+func TestContainsFields(t *testing.T) {
+	live := map[string]any{
+		"metadata": map[string]any{"name": "plugin", "uid": "1234"},
+		"spec": map[string]any{
+			"containers": []any{
+				map[string]any{"name": "plugin", "image": "quay.io/org/driver:v1", "terminationMessagePath": "/dev/termination-log"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		desired map[string]any
+		want    bool
+	}{
+		{
+			name:    "subset with defaulted fields in live",
+			desired: map[string]any{"spec": map[string]any{"containers": []any{map[string]any{"name": "plugin", "image": "quay.io/org/driver:v1"}}}},
+			want:    true,
+		},
+		{
+			name:    "changed value",
+			desired: map[string]any{"spec": map[string]any{"containers": []any{map[string]any{"name": "plugin", "image": "quay.io/org/driver:v2"}}}},
+			want:    false,
+		},
+		{
+			name:    "missing field",
+			desired: map[string]any{"metadata": map[string]any{"labels": map[string]any{"app": "plugin"}}},
+			want:    false,
+		},
+		{
+			name: "extra list item",
+			desired: map[string]any{"spec": map[string]any{"containers": []any{
+				map[string]any{"name": "plugin"},
+				map[string]any{"name": "sidecar"},
+			}}},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := containsFields(live, tt.desired); got != tt.want {
+				t.Errorf("containsFields() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
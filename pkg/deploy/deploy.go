@@ -3,38 +3,70 @@ package deploy
 import (
 	"context"
 	"fmt"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/klog/v2"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	"github.com/Tal-or/dra-deployer/pkg/helm"
+	"github.com/Tal-or/dra-deployer/pkg/inventory"
 	"github.com/Tal-or/dra-deployer/pkg/params"
 )
 
+const (
+	// FieldManager is the field manager used for server-side apply, it must stay
+	// stable across runs so that fields dropped from the chart are removed from the objects
+	FieldManager = "dra-deployer"
+
+	// managedByLabel is set to managedByValue on the namespaces created by Deploy, only
+	// those are deleted by Delete
+	managedByLabel = "app.kubernetes.io/managed-by"
+	managedByValue = "dra-deployer"
+)
+
+// Result describes the outcome of applying a single object
+type Result string
+
+const (
+	ResultCreated    Result = "created"
+	ResultConfigured Result = "configured"
+	ResultUnchanged  Result = "unchanged"
+)
+
 type Options struct {
-	Namespace string
-	Image     string
-	Command   string
+	ForceConflicts bool          // ForceConflicts takes over fields owned by other field managers instead of failing
+	Wait           bool          // Wait blocks until the kubelet plugin DaemonSet has rolled out
+	Timeout        time.Duration // Timeout bounds the wait for the rollout, DefaultWaitTimeout is used when zero
+	Prune          bool          // Prune deletes the objects of the previous inventory which are no longer rendered
+	DryRun         DryRunStrategy
+	Signature      *inventory.Signature // Signature records the signature verification of the image in the inventory, nil if not verified
 }
 
-func Deploy(ctx context.Context, cli client.Client, envConfig params.EnvConfig) error {
-	klog.InfoS("deploying manifests to cluster", "namespace", envConfig.Namespace)
+type DeleteOptions struct {
+	DryRun  DryRunStrategy
+	Wait    bool          // Wait blocks until the namespace and the plugin pods are gone, then deletes the orphaned ResourceSlices
+	Timeout time.Duration // Timeout bounds the wait for the deletion, DefaultWaitTimeout is used when zero
+}
 
-	// Check and create namespace if needed
-	err := createNamespaceIfNeeded(ctx, cli, envConfig.Namespace)
+func Deploy(ctx context.Context, cli client.Client, envConfig params.EnvConfig, opts Options) error {
+	// Load Helm chart
+	chartLoader, err := helm.NewChartLoaderForConfig(envConfig)
 	if err != nil {
-		return fmt.Errorf("failed to create namespace: %w", err)
+		return fmt.Errorf("failed to load Helm chart: %w", err)
 	}
 
-	// Load Helm chart
-	chartLoader, err := helm.NewChartLoader("")
+	releaseName, err := chartLoader.ReleaseName(envConfig)
 	if err != nil {
-		return fmt.Errorf("failed to load Helm chart: %w", err)
+		return err
 	}
+	klog.InfoS("deploying manifests to cluster", "namespace", envConfig.Namespace, "release", releaseName, "dryRun", opts.DryRun)
 
 	objects, err := chartLoader.Render(envConfig)
 
@@ -42,22 +74,137 @@ func Deploy(ctx context.Context, cli client.Client, envConfig params.EnvConfig)
 		return fmt.Errorf("failed to render Helm chart: %w", err)
 	}
 
+	// Never take over the cluster-scoped objects of another install, nor leave the install
+	// of an earlier version running next to this one
+	if err := checkReleaseOwnership(ctx, cli, objects, releaseName); err != nil {
+		return err
+	}
+	if err := checkLegacyRelease(ctx, cli, chartLoader, envConfig, releaseName); err != nil {
+		return err
+	}
+
+	// Check and create namespace if needed
+	created, err := createNamespaceIfNeeded(ctx, cli, envConfig.Namespace, opts.DryRun)
+	if err != nil {
+		return fmt.Errorf("failed to create namespace: %w", err)
+	}
+
+	// A server dry-run does not persist the namespace, so the API server rejects the namespaced
+	// objects of a fresh install; dry-run them on the client instead
+	namespacedOpts := opts
+	if created && opts.DryRun == DryRunServer {
+		klog.InfoS("Namespace does not exist yet, dry-running the namespaced objects on the client", "namespace", envConfig.Namespace)
+		namespacedOpts.DryRun = DryRunClient
+	}
+
 	// Deploy all objects
 	for _, obj := range objects {
-		key := fmt.Sprintf("%s/%s/%s", obj.GetObjectKind().GroupVersionKind().Kind, obj.GetNamespace(), obj.GetName())
-		klog.V(4).InfoS("creating/updating", "key", key)
-		result, err := controllerutil.CreateOrUpdate(ctx, cli, obj, nil)
+		key := objectKey(obj)
+		objOpts := opts
+		if obj.GetNamespace() != "" {
+			objOpts = namespacedOpts
+		}
+		klog.V(4).InfoS("applying", "key", key)
+		result, err := applyObject(ctx, cli, obj, objOpts)
 		if err != nil {
-			return fmt.Errorf("failed to create/update object %s: %w", key, err)
+			return fmt.Errorf("failed to apply object %s: %w", key, err)
 		}
-		klog.V(4).InfoS("created/updated", "key", key, "result", result)
+		klog.InfoS("Applied object", "key", key, "result", string(result)+objOpts.DryRun.suffix())
+	}
+
+	// Record what was applied, so objects dropped from the chart can be pruned and deleted later,
+	// and the driver, so delete finds its ResourceSlices without the values the chart was rendered with
+	driverName, err := chartLoader.DriverName(envConfig)
+	if err != nil {
+		klog.V(2).InfoS("Not recording the driver of the install", "err", err)
+	}
+	meta := inventory.Metadata{Driver: driverName, Signature: opts.Signature}
+	err = updateInventory(ctx, cli, envConfig.Namespace, releaseName, objects, meta, opts.Prune, namespacedOpts.DryRun)
+	if err != nil {
+		return err
+	}
+
+	if opts.Wait && opts.DryRun.Enabled() {
+		klog.InfoS("Skipping wait for the rollout in dry-run mode")
+	} else if opts.Wait {
+		timeout := opts.Timeout
+		if timeout == 0 {
+			timeout = DefaultWaitTimeout
+		}
+		for _, obj := range objects {
+			if obj.GroupVersionKind().GroupKind() != (schema.GroupKind{Group: "apps", Kind: "DaemonSet"}) {
+				continue
+			}
+			err := WaitForDaemonSet(ctx, cli, client.ObjectKeyFromObject(obj), timeout)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if opts.DryRun.Enabled() {
+		klog.InfoS("Dry run completed, no changes were persisted", "dryRun", opts.DryRun)
+		return nil
 	}
 
 	klog.InfoS("Successfully deployed core manifests to cluster")
 	return nil
 }
 
-func createNamespaceIfNeeded(ctx context.Context, cli client.Client, namespace string) error {
+// applyObject applies obj using server-side apply and reports whether it was
+// created, configured or left unchanged by comparing the live object with the object
+// returned by the apply, like diff does. Dry-run requests never bump the resource version,
+// so it can't tell the results apart. In client dry-run mode nothing is sent: the object is
+// unchanged if the live object already holds every rendered field, which may report an object
+// the API server would normalize (e.g. quantities) as configured.
+func applyObject(ctx context.Context, cli client.Client, obj *unstructured.Unstructured, opts Options) (Result, error) {
+	live, err := GetLive(ctx, cli, obj)
+	if err != nil {
+		return "", err
+	}
+
+	if opts.DryRun == DryRunClient {
+		if live == nil {
+			return ResultCreated, nil
+		}
+		if containsFields(live.Object, obj.Object) {
+			return ResultUnchanged, nil
+		}
+		return ResultConfigured, nil
+	}
+
+	applyOpts := []client.ApplyOption{client.FieldOwner(FieldManager)}
+	if opts.ForceConflicts {
+		applyOpts = append(applyOpts, client.ForceOwnership)
+	}
+	applyOpts = append(applyOpts, opts.DryRun.applyOptions()...)
+
+	applied := obj.DeepCopy()
+	err = cli.Apply(ctx, client.ApplyConfigurationFromUnstructured(applied), applyOpts...)
+	if err != nil {
+		if errors.IsConflict(err) {
+			return "", fmt.Errorf("%w (use --force-conflicts to take ownership of the conflicting fields)", err)
+		}
+		return "", err
+	}
+
+	if live == nil {
+		return ResultCreated, nil
+	}
+	if equality.Semantic.DeepEqual(Normalize(live).Object, Normalize(applied).Object) {
+		return ResultUnchanged, nil
+	}
+	return ResultConfigured, nil
+}
+
+// objectKey returns a human readable identifier of obj for logs and errors
+func objectKey(obj *unstructured.Unstructured) string {
+	return fmt.Sprintf("%s/%s/%s", obj.GetKind(), obj.GetNamespace(), obj.GetName())
+}
+
+// createNamespaceIfNeeded creates namespace unless it exists and returns true if it was
+// (or, in dry-run mode, would have been) created
+func createNamespaceIfNeeded(ctx context.Context, cli client.Client, namespace string, dryRun DryRunStrategy) (bool, error) {
 	// Check and create namespace if needed
 	ns := &corev1.Namespace{}
 	err := cli.Get(ctx, client.ObjectKey{Name: namespace}, ns)
@@ -66,79 +213,244 @@ func createNamespaceIfNeeded(ctx context.Context, cli client.Client, namespace s
 			// Create namespace
 			ns.Name = namespace
 			ns.Labels = map[string]string{
-				"app.kubernetes.io/managed-by": "dra-deployer",
+				managedByLabel: managedByValue,
+			}
+			if dryRun == DryRunClient {
+				klog.InfoS("Created namespace"+dryRun.suffix(), "namespace", namespace)
+				return true, nil
 			}
-			err = cli.Create(ctx, ns)
+			err = cli.Create(ctx, ns, dryRun.createOptions()...)
 			if err != nil {
-				return fmt.Errorf("failed to create namespace: %w", err)
+				return false, fmt.Errorf("failed to create namespace: %w", err)
 			}
-			klog.InfoS("Created namespace", "namespace", namespace)
-		} else {
-			return fmt.Errorf("failed to get namespace: %w", err)
+			klog.InfoS("Created namespace"+dryRun.suffix(), "namespace", namespace)
+			return true, nil
 		}
-	} else {
-		klog.V(4).InfoS("Namespace already exists", "namespace", namespace)
+		return false, fmt.Errorf("failed to get namespace: %w", err)
 	}
-	return nil
+	klog.V(4).InfoS("Namespace already exists", "namespace", namespace)
+	return false, nil
 }
 
-// Delete removes all DRA plugin manifests from the cluster
-func Delete(ctx context.Context, cli client.Client, namespace string) error {
-	klog.InfoS("Deleting manifests from cluster", "namespace", namespace)
+// Delete removes all DRA plugin manifests from the cluster.
+// The objects to delete are taken from the inventory recorded by Deploy; the chart
+// is only rendered for installs which have no inventory.
+func Delete(ctx context.Context, cli client.Client, envConfig params.EnvConfig, opts DeleteOptions) error {
+	namespace := envConfig.Namespace
 
 	// Load Helm chart
-	chartLoader, err := helm.NewChartLoader("")
+	chartLoader, err := helm.NewChartLoaderForConfig(envConfig)
 	if err != nil {
 		return fmt.Errorf("failed to load Helm chart: %w", err)
 	}
 
-	objects, err := chartLoader.Render(params.EnvConfig{
-		Namespace: namespace,
-		Values:    nil,
-	})
+	releaseName, err := chartLoader.ReleaseName(envConfig)
 	if err != nil {
-		return fmt.Errorf("failed to render Helm chart: %w", err)
+		return err
 	}
+	klog.InfoS("Deleting manifests from cluster", "namespace", namespace, "release", releaseName, "dryRun", opts.DryRun)
 
-	// Delete namespace (this will cascade delete namespaced resources like ServiceAccount and DaemonSet)
-	ns := &corev1.Namespace{}
-	ns.Name = namespace
+	// The objects of an install made by an earlier version have other names, they would be left behind
+	if err := checkLegacyRelease(ctx, cli, chartLoader, envConfig, releaseName); err != nil {
+		return err
+	}
 
-	klog.V(2).InfoS("Deleting namespace", "namespace", namespace)
-	err = cli.Delete(ctx, ns)
+	objects, err := installedObjects(ctx, cli, chartLoader, envConfig, releaseName)
 	if err != nil {
-		if errors.IsNotFound(err) {
-			klog.V(4).InfoS("Namespace already deleted", "namespace", namespace)
-		} else {
-			return fmt.Errorf("failed to delete namespace: %w", err)
+		return err
+	}
+
+	// Read the driver before its inventory is deleted
+	var driverName string
+	if opts.Wait {
+		driverName, err = installedDriver(ctx, cli, chartLoader, envConfig, releaseName)
+		if err != nil {
+			return err
 		}
-	} else {
-		klog.InfoS("Deleted namespace", "namespace", namespace)
 	}
 
-	// Delete objects
+	// Collect the plugin pods before their DaemonSet is deleted, to wait for their termination
+	var waitFor []*unstructured.Unstructured
+	if opts.Wait {
+		waitFor, err = pluginPods(ctx, cli, objects)
+		if err != nil {
+			return err
+		}
+		waitFor = append(waitFor, objects...)
+	}
+
+	// Delete objects in the reverse install order, so the plugin DaemonSet goes away
+	// before the RBAC and SCC it runs with
+	helm.SortByDeleteOrder(objects)
 	for _, obj := range objects {
-		// Skip namespaced objects
-		if obj.GetNamespace() != "" {
-			continue
+		if err := deleteObject(ctx, cli, obj, opts.DryRun); err != nil {
+			return err
 		}
+	}
 
-		// Delete cluster-scoped objects
-		key := fmt.Sprintf("%s/%s", obj.GetObjectKind().GroupVersionKind().Kind, obj.GetName())
-		klog.V(2).InfoS("Deleting object", "key", key)
+	// Only delete a namespace created by the deployer, it may be shared with other workloads
+	// or hold the installs of other releases
+	owned, err := namespaceOwned(ctx, cli, namespace, releaseName)
+	if err != nil {
+		return err
+	}
+	if owned {
+		// Delete namespace last (this will cascade delete the remaining namespaced resources like the inventory)
+		ns := &unstructured.Unstructured{}
+		ns.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Namespace"))
+		ns.SetName(namespace)
+		if err := deleteObject(ctx, cli, ns, opts.DryRun); err != nil {
+			return err
+		}
+		waitFor = append(waitFor, ns)
+	} else {
+		if err := deleteObject(ctx, cli, inventory.Object(namespace, releaseName), opts.DryRun); err != nil {
+			return err
+		}
+	}
 
-		err := cli.Delete(ctx, obj)
-		if err != nil {
-			if errors.IsNotFound(err) {
-				klog.V(4).InfoS("Resource already deleted", "key", key)
-			} else {
-				return fmt.Errorf("failed to delete object %s: %w", key, err)
-			}
-		} else {
-			klog.InfoS("Deleted resource", "key", key)
+	if opts.Wait {
+		if err := waitAndCleanup(ctx, cli, driverName, envConfig.ResourceAPIVersions, waitFor, opts); err != nil {
+			return err
 		}
 	}
 
+	if opts.DryRun.Enabled() {
+		klog.InfoS("Dry run completed, no objects were deleted", "dryRun", opts.DryRun)
+		return nil
+	}
+
 	klog.InfoS("Successfully deleted all manifests from cluster")
 	return nil
 }
+
+// waitAndCleanup waits until the deleted objects are gone, then deletes the ResourceSlices left behind
+// by driverName and reports the ResourceClaims still allocated from it
+func waitAndCleanup(ctx context.Context, cli client.Client, driverName string, apiVersions []string, waitFor []*unstructured.Unstructured, opts DeleteOptions) error {
+	if opts.DryRun.Enabled() {
+		klog.InfoS("Skipping wait for the deletion in dry-run mode")
+	} else {
+		timeout := opts.Timeout
+		if timeout == 0 {
+			timeout = DefaultWaitTimeout
+		}
+		if err := WaitForDeletion(ctx, cli, waitFor, timeout); err != nil {
+			return err
+		}
+	}
+
+	if err := deleteResourceSlices(ctx, cli, driverName, apiVersions, opts.DryRun); err != nil {
+		return err
+	}
+
+	claims, err := allocatedClaims(ctx, cli, driverName, apiVersions)
+	if err != nil {
+		return err
+	}
+	for _, claim := range claims {
+		klog.InfoS("ResourceClaim still has devices allocated from the deleted driver", "claim", claim, "driver", driverName)
+	}
+	if len(claims) > 0 {
+		klog.InfoS("Delete the workloads using these ResourceClaims to release the devices", "count", len(claims))
+	}
+	return nil
+}
+
+// namespaceOwned returns true if namespace exists, carries the label set by createNamespaceIfNeeded
+// and holds no install of a release other than releaseName
+func namespaceOwned(ctx context.Context, cli client.Client, namespace, releaseName string) (bool, error) {
+	ns := &corev1.Namespace{}
+	if err := cli.Get(ctx, client.ObjectKey{Name: namespace}, ns); err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to get namespace: %w", err)
+	}
+	if ns.Labels[managedByLabel] != managedByValue {
+		klog.InfoS("Keeping namespace not created by dra-deployer", "namespace", namespace, "label", managedByLabel+"="+managedByValue)
+		return false, nil
+	}
+
+	releases, err := inventory.Releases(ctx, cli, namespace)
+	if err != nil {
+		return false, err
+	}
+	for _, release := range releases {
+		if release == releaseName {
+			continue
+		}
+		klog.InfoS("Keeping namespace holding other releases", "namespace", namespace, "release", release)
+		return false, nil
+	}
+	return true, nil
+}
+
+// installedObjects returns the objects recorded in the inventory of the install namespace,
+// falling back to rendering the chart when no inventory exists
+func installedObjects(ctx context.Context, cli client.Client, chartLoader *helm.ChartLoader, envConfig params.EnvConfig, releaseName string) ([]*unstructured.Unstructured, error) {
+	entries, found, err := inventory.Load(ctx, cli, envConfig.Namespace, releaseName)
+	if err != nil {
+		return nil, err
+	}
+
+	if found {
+		klog.V(2).InfoS("Using recorded inventory", "namespace", envConfig.Namespace, "objects", len(entries))
+		objects := make([]*unstructured.Unstructured, 0, len(entries))
+		for _, entry := range entries {
+			objects = append(objects, entry.Object())
+		}
+		return objects, nil
+	}
+
+	klog.V(2).InfoS("No inventory found, rendering the chart to find the installed objects", "namespace", envConfig.Namespace)
+
+	objects, err := chartLoader.Render(envConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render Helm chart: %w", err)
+	}
+	return objects, nil
+}
+
+// installedDriver returns the DRA driver name recorded in the inventory of the install namespace,
+// falling back to the driver.name the chart renders for inventories which did not record it
+func installedDriver(ctx context.Context, cli client.Client, chartLoader *helm.ChartLoader, envConfig params.EnvConfig, releaseName string) (string, error) {
+	meta, err := inventory.LoadMetadata(ctx, cli, envConfig.Namespace, releaseName)
+	if err != nil {
+		return "", err
+	}
+	if meta.Driver != "" {
+		return meta.Driver, nil
+	}
+
+	klog.V(2).InfoS("No driver recorded in the inventory, using the driver of the chart", "namespace", envConfig.Namespace)
+	return chartLoader.DriverName(envConfig)
+}
+
+// deleteObject deletes obj, treating objects which are already gone as deleted.
+// In client dry-run mode the object is only looked up.
+func deleteObject(ctx context.Context, cli client.Client, obj *unstructured.Unstructured, dryRun DryRunStrategy) error {
+	key := objectKey(obj)
+	klog.V(2).InfoS("Deleting object", "key", key)
+
+	var err error
+	if dryRun == DryRunClient {
+		var live *unstructured.Unstructured
+		live, err = GetLive(ctx, cli, obj)
+		if err == nil && live == nil {
+			klog.V(4).InfoS("Resource already deleted", "key", key)
+			return nil
+		}
+	} else {
+		err = cli.Delete(ctx, obj, dryRun.deleteOptions()...)
+	}
+	if err != nil {
+		if errors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			klog.V(4).InfoS("Resource already deleted", "key", key)
+			return nil
+		}
+		return fmt.Errorf("failed to delete object %s: %w", key, err)
+	}
+
+	klog.InfoS("Deleted resource"+dryRun.suffix(), "key", key)
+	return nil
+}
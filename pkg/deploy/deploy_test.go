@@ -0,0 +1,478 @@
+package deploy
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	resourcev1 "k8s.io/api/resource/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"github.com/Tal-or/dra-deployer/pkg/inventory"
+	"github.com/Tal-or/dra-deployer/pkg/params"
+)
+
+// newFakeClient returns a fake client serving the built-in types and holding objs
+func newFakeClient(objs ...client.Object) client.Client {
+	return newFakeClientBuilder().WithObjects(objs...).WithInterceptorFuncs(interceptor.Funcs{
+		Apply: fakeApply,
+	}).Build()
+}
+
+func newFakeClientBuilder() *fake.ClientBuilder {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	return fake.NewClientBuilder().WithScheme(scheme)
+}
+
+// fakeApply applies obj like the API server: unlike the fake client, it persists nothing for dry-run requests
+func fakeApply(ctx context.Context, c client.WithWatch, obj runtime.ApplyConfiguration, opts ...client.ApplyOption) error {
+	applyOpts := &client.ApplyOptions{}
+	applyOpts.ApplyOptions(opts)
+	for _, dryRun := range applyOpts.DryRun {
+		if dryRun == metav1.DryRunAll {
+			return nil
+		}
+	}
+	return c.Apply(ctx, obj, opts...)
+}
+
+// newDaemonSet returns the plugin DaemonSet running image
+func newDaemonSet(image string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "apps/v1",
+		"kind":       "DaemonSet",
+		"metadata":   map[string]any{"namespace": "dra", "name": "plugin"},
+		"spec": map[string]any{
+			"selector": map[string]any{"matchLabels": map[string]any{"app": "plugin"}},
+			"template": map[string]any{
+				"metadata": map[string]any{"labels": map[string]any{"app": "plugin"}},
+				"spec": map[string]any{
+					"containers": []any{
+						map[string]any{"name": "plugin", "image": image},
+					},
+				},
+			},
+		},
+	}}
+}
+
+// daemonSetImage returns the image of the live plugin DaemonSet
+func daemonSetImage(t *testing.T, cli client.Client) string {
+	t.Helper()
+	live, err := GetLive(context.Background(), cli, newDaemonSet(""))
+	if err != nil || live == nil {
+		t.Fatalf("Failed to get DaemonSet: %v", err)
+	}
+	containers, _, _ := unstructured.NestedSlice(live.Object, "spec", "template", "spec", "containers")
+	if len(containers) == 0 {
+		t.Fatal("DaemonSet has no containers")
+	}
+	image, _ := containers[0].(map[string]any)["image"].(string)
+	return image
+}
+
+func TestApplyObject(t *testing.T) {
+	ctx := context.Background()
+	cli := newFakeClient()
+
+	result, err := applyObject(ctx, cli, newDaemonSet("quay.io/org/driver:v1"), Options{})
+	if err != nil {
+		t.Fatalf("applyObject() failed: %v", err)
+	}
+	if result != ResultCreated {
+		t.Errorf("first apply = %q, want %q", result, ResultCreated)
+	}
+
+	result, err = applyObject(ctx, cli, newDaemonSet("quay.io/org/driver:v1"), Options{})
+	if err != nil {
+		t.Fatalf("applyObject() failed: %v", err)
+	}
+	if result != ResultUnchanged {
+		t.Errorf("apply of the same object = %q, want %q", result, ResultUnchanged)
+	}
+
+	result, err = applyObject(ctx, cli, newDaemonSet("quay.io/org/driver:v2"), Options{})
+	if err != nil {
+		t.Fatalf("applyObject() failed: %v", err)
+	}
+	if result != ResultConfigured {
+		t.Errorf("apply of a new image = %q, want %q", result, ResultConfigured)
+	}
+	if got := daemonSetImage(t, cli); got != "quay.io/org/driver:v2" {
+		t.Errorf("live image = %q, want the applied image", got)
+	}
+}
+
+func TestApplyObjectConflict(t *testing.T) {
+	ctx := context.Background()
+	cli := newFakeClient()
+
+	// Another field manager owns the image
+	other := newDaemonSet("quay.io/org/driver:v1")
+	err := cli.Apply(ctx, client.ApplyConfigurationFromUnstructured(other), client.FieldOwner("kubectl"))
+	if err != nil {
+		t.Fatalf("Failed to apply as another field manager: %v", err)
+	}
+
+	_, err = applyObject(ctx, cli, newDaemonSet("quay.io/org/driver:v2"), Options{})
+	if err == nil {
+		t.Fatal("applyObject() expected a conflict error, got nil")
+	}
+	if !strings.Contains(err.Error(), "--force-conflicts") {
+		t.Errorf("applyObject() error %q does not hint at --force-conflicts", err)
+	}
+
+	result, err := applyObject(ctx, cli, newDaemonSet("quay.io/org/driver:v2"), Options{ForceConflicts: true})
+	if err != nil {
+		t.Fatalf("applyObject() with ForceConflicts failed: %v", err)
+	}
+	if result != ResultConfigured {
+		t.Errorf("forced apply = %q, want %q", result, ResultConfigured)
+	}
+	if got := daemonSetImage(t, cli); got != "quay.io/org/driver:v2" {
+		t.Errorf("live image = %q, want the applied image", got)
+	}
+}
+
+func TestApplyObjectDryRun(t *testing.T) {
+	ctx := context.Background()
+
+	for _, dryRun := range []DryRunStrategy{DryRunClient, DryRunServer} {
+		t.Run(string(dryRun), func(t *testing.T) {
+			cli := newFakeClient()
+
+			result, err := applyObject(ctx, cli, newDaemonSet("quay.io/org/driver:v1"), Options{DryRun: dryRun})
+			if err != nil {
+				t.Fatalf("applyObject() failed: %v", err)
+			}
+			if result != ResultCreated {
+				t.Errorf("dry-run apply of a new object = %q, want %q", result, ResultCreated)
+			}
+			if live, err := GetLive(ctx, cli, newDaemonSet("")); err != nil || live != nil {
+				t.Fatalf("dry-run apply created the object (err %v)", err)
+			}
+
+			if _, err := applyObject(ctx, cli, newDaemonSet("quay.io/org/driver:v1"), Options{}); err != nil {
+				t.Fatalf("applyObject() failed: %v", err)
+			}
+			result, err = applyObject(ctx, cli, newDaemonSet("quay.io/org/driver:v2"), Options{DryRun: dryRun})
+			if err != nil {
+				t.Fatalf("applyObject() failed: %v", err)
+			}
+			if result != ResultConfigured {
+				t.Errorf("dry-run apply of a new image = %q, want %q", result, ResultConfigured)
+			}
+			if got := daemonSetImage(t, cli); got != "quay.io/org/driver:v1" {
+				t.Errorf("dry-run apply changed the live image to %q", got)
+			}
+			if dryRun != DryRunClient {
+				return
+			}
+
+			// The live object holds every rendered field
+			result, err = applyObject(ctx, cli, newDaemonSet("quay.io/org/driver:v1"), Options{DryRun: dryRun})
+			if err != nil {
+				t.Fatalf("applyObject() failed: %v", err)
+			}
+			if result != ResultUnchanged {
+				t.Errorf("dry-run apply of the live image = %q, want %q", result, ResultUnchanged)
+			}
+		})
+	}
+}
+
+func TestDeleteObjectDryRun(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		dryRun DryRunStrategy
+		kept   bool
+	}{
+		{dryRun: DryRunNone, kept: false},
+		{dryRun: DryRunClient, kept: true},
+		{dryRun: DryRunServer, kept: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.dryRun), func(t *testing.T) {
+			cli := newFakeClient()
+			if _, err := applyObject(ctx, cli, newDaemonSet("quay.io/org/driver:v1"), Options{}); err != nil {
+				t.Fatalf("applyObject() failed: %v", err)
+			}
+
+			if err := deleteObject(ctx, cli, newDaemonSet(""), tt.dryRun); err != nil {
+				t.Fatalf("deleteObject() failed: %v", err)
+			}
+			live, err := GetLive(ctx, cli, newDaemonSet(""))
+			if err != nil {
+				t.Fatalf("GetLive() failed: %v", err)
+			}
+			if (live != nil) != tt.kept {
+				t.Errorf("object kept = %v, want %v", live != nil, tt.kept)
+			}
+
+			// Objects already gone are treated as deleted
+			if err := deleteObject(ctx, cli, newDaemonSet(""), tt.dryRun); err != nil {
+				t.Errorf("deleteObject() of a deleted object failed: %v", err)
+			}
+		})
+	}
+}
+
+func TestDeployServerDryRunFreshInstall(t *testing.T) {
+	ctx := context.Background()
+
+	// Like the NamespaceLifecycle admission plugin, reject namespaced objects of missing namespaces
+	cli := newFakeClientBuilder().WithInterceptorFuncs(interceptor.Funcs{
+		Apply: func(ctx context.Context, c client.WithWatch, obj runtime.ApplyConfiguration, opts ...client.ApplyOption) error {
+			data, err := json.Marshal(obj)
+			if err != nil {
+				return err
+			}
+			u := &unstructured.Unstructured{}
+			if err := u.UnmarshalJSON(data); err != nil {
+				return err
+			}
+			if ns := u.GetNamespace(); ns != "" {
+				if err := c.Get(ctx, client.ObjectKey{Name: ns}, &corev1.Namespace{}); err != nil {
+					return err
+				}
+			}
+			return fakeApply(ctx, c, obj, opts...)
+		},
+	}).Build()
+
+	envConfig := params.EnvConfig{Namespace: "dra-fresh"}
+	if err := Deploy(ctx, cli, envConfig, Options{DryRun: DryRunServer}); err != nil {
+		t.Fatalf("Deploy() with server dry-run on a fresh install failed: %v", err)
+	}
+
+	if err := cli.Get(ctx, client.ObjectKey{Name: "dra-fresh"}, &corev1.Namespace{}); !errors.IsNotFound(err) {
+		t.Errorf("server dry-run created the namespace (err %v)", err)
+	}
+	cms := &corev1.ConfigMapList{}
+	if err := cli.List(ctx, cms, client.InNamespace("dra-fresh")); err != nil || len(cms.Items) > 0 {
+		t.Errorf("server dry-run saved an inventory (err %v)", err)
+	}
+}
+
+// newNamespace returns namespace, carrying the label set when the deployer created it if managed is set
+func newNamespace(name string, managed bool) *corev1.Namespace {
+	ns := &corev1.Namespace{}
+	ns.Name = name
+	if managed {
+		ns.Labels = map[string]string{managedByLabel: managedByValue}
+	}
+	return ns
+}
+
+// newInventory returns the inventory ConfigMap of release in namespace
+func newInventory(namespace, release string) *corev1.ConfigMap {
+	cm := &corev1.ConfigMap{}
+	cm.Namespace = namespace
+	cm.Name = inventory.Name(release)
+	cm.Labels = map[string]string{
+		"app.kubernetes.io/managed-by": "dra-deployer",
+		"app.kubernetes.io/instance":   release,
+	}
+	cm.Data = map[string]string{"objects": "[]"}
+	return cm
+}
+
+func TestNamespaceOwned(t *testing.T) {
+	tests := []struct {
+		name    string
+		objects []client.Object
+		want    bool
+	}{
+		{
+			name:    "missing namespace",
+			objects: nil,
+			want:    false,
+		},
+		{
+			name:    "namespace not created by the deployer",
+			objects: []client.Object{newNamespace("kube-system", false)},
+			want:    false,
+		},
+		{
+			name:    "namespace created by the deployer",
+			objects: []client.Object{newNamespace("kube-system", true), newInventory("kube-system", "stable")},
+			want:    true,
+		},
+		{
+			name:    "namespace holding another release",
+			objects: []client.Object{newNamespace("kube-system", true), newInventory("kube-system", "stable"), newInventory("kube-system", "canary")},
+			want:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cli := newFakeClient(tt.objects...)
+			got, err := namespaceOwned(context.Background(), cli, "kube-system", "stable")
+			if err != nil {
+				t.Fatalf("namespaceOwned() failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("namespaceOwned() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeleteKeepsNamespace(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		objects []client.Object
+		deleted bool
+	}{
+		{
+			name:    "namespace not created by the deployer",
+			objects: []client.Object{newNamespace("kube-system", false), newInventory("kube-system", "stable")},
+			deleted: false,
+		},
+		{
+			name:    "namespace created by the deployer",
+			objects: []client.Object{newNamespace("kube-system", true), newInventory("kube-system", "stable")},
+			deleted: true,
+		},
+		{
+			name:    "namespace holding another release",
+			objects: []client.Object{newNamespace("kube-system", true), newInventory("kube-system", "stable"), newInventory("kube-system", "canary")},
+			deleted: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cli := newFakeClient(tt.objects...)
+			envConfig := params.EnvConfig{Namespace: "kube-system", ReleaseName: "stable"}
+			if err := Delete(ctx, cli, envConfig, DeleteOptions{}); err != nil {
+				t.Fatalf("Delete() failed: %v", err)
+			}
+
+			err := cli.Get(ctx, client.ObjectKey{Name: "kube-system"}, &corev1.Namespace{})
+			if deleted := errors.IsNotFound(err); deleted != tt.deleted {
+				t.Errorf("namespace deleted = %v, want %v (err %v)", deleted, tt.deleted, err)
+			}
+			if tt.deleted {
+				return
+			}
+
+			// The inventory of the release is removed, the ones of other releases are kept
+			err = cli.Get(ctx, client.ObjectKey{Namespace: "kube-system", Name: inventory.Name("stable")}, &corev1.ConfigMap{})
+			if !errors.IsNotFound(err) {
+				t.Errorf("inventory of the deleted release kept (err %v)", err)
+			}
+			for _, obj := range tt.objects {
+				if cm, ok := obj.(*corev1.ConfigMap); ok && cm.Labels["app.kubernetes.io/instance"] != "stable" {
+					if err := cli.Get(ctx, client.ObjectKeyFromObject(cm), &corev1.ConfigMap{}); err != nil {
+						t.Errorf("inventory %s of another release deleted (err %v)", cm.Name, err)
+					}
+				}
+			}
+		})
+	}
+}
+
+// newResourceSlice returns a ResourceSlice published by driver
+func newResourceSlice(name, driver string) *resourcev1.ResourceSlice {
+	slice := &resourcev1.ResourceSlice{}
+	slice.Name = name
+	slice.Spec.Driver = driver
+	return slice
+}
+
+func TestDeleteCleansUpRecordedDriver(t *testing.T) {
+	ctx := context.Background()
+
+	cli := newFakeClientBuilder().
+		WithObjects(newResourceSlice("custom", "custom.example.com"), newResourceSlice("memory", "manager.memory.com")).
+		WithIndex(&resourcev1.ResourceSlice{}, "spec.driver", func(obj client.Object) []string {
+			// The slices are listed as unstructured objects
+			driver, _, _ := unstructured.NestedString(obj.(*unstructured.Unstructured).Object, "spec", "driver")
+			return []string{driver}
+		}).
+		WithInterceptorFuncs(interceptor.Funcs{Apply: fakeApply}).
+		Build()
+
+	// Installed with --set driver.name=custom.example.com
+	envConfig := params.EnvConfig{
+		Namespace:           "dra",
+		ResourceAPIVersions: []string{"v1"},
+		Values:              map[string]any{"driver": map[string]any{"name": "custom.example.com"}},
+	}
+	if err := Deploy(ctx, cli, envConfig, Options{}); err != nil {
+		t.Fatalf("Deploy() failed: %v", err)
+	}
+
+	// delete takes no values, the chart renders the default driver name
+	envConfig.Values = nil
+	if err := Delete(ctx, cli, envConfig, DeleteOptions{Wait: true, Timeout: 10 * time.Second}); err != nil {
+		t.Fatalf("Delete() failed: %v", err)
+	}
+
+	if err := cli.Get(ctx, client.ObjectKey{Name: "custom"}, &resourcev1.ResourceSlice{}); !errors.IsNotFound(err) {
+		t.Errorf("ResourceSlice of the deleted driver kept (err %v)", err)
+	}
+	if err := cli.Get(ctx, client.ObjectKey{Name: "memory"}, &resourcev1.ResourceSlice{}); err != nil {
+		t.Errorf("ResourceSlice of another driver deleted (err %v)", err)
+	}
+}
+
+func TestLegacyRelease(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		release string
+		delete  bool
+		wantErr bool
+	}{
+		{name: "apply of the default release", wantErr: true},
+		{name: "delete of the default release", delete: true, wantErr: true},
+		{name: "apply of the legacy release", release: "0.1.0"},
+		{name: "delete of the legacy release", release: "0.1.0", delete: true},
+		{name: "apply of another release", release: "stable"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Installed by an earlier version, which named the release after the chart app version
+			role := &rbacv1.ClusterRole{}
+			role.Name = "0.1.0-dra-driver-memory-role"
+			role.Labels = map[string]string{instanceLabel: "0.1.0"}
+			cli := newFakeClient(role)
+
+			envConfig := params.EnvConfig{Namespace: "dra", ReleaseName: tt.release}
+			var err error
+			if tt.delete {
+				err = Delete(ctx, cli, envConfig, DeleteOptions{})
+			} else {
+				err = Deploy(ctx, cli, envConfig, Options{})
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !strings.Contains(err.Error(), "--release-name 0.1.0") {
+				t.Errorf("Expected the error to point to --release-name 0.1.0, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	"github.com/Tal-or/dra-deployer/pkg/diff"
+	"github.com/Tal-or/dra-deployer/pkg/helm"
+	"github.com/Tal-or/dra-deployer/pkg/params"
+)
+
+// Diff renders the chart and writes to w a unified diff per object between its live
+// state and the result of a server-side dry-run apply, ignoring managed fields and status.
+// It returns true if any object differs.
+func Diff(ctx context.Context, cli client.Client, envConfig params.EnvConfig, w io.Writer) (bool, error) {
+	klog.V(2).InfoS("Comparing manifests with cluster state", "namespace", envConfig.Namespace)
+
+	chartLoader, err := helm.NewChartLoaderForConfig(envConfig)
+	if err != nil {
+		return false, fmt.Errorf("failed to load Helm chart: %w", err)
+	}
+
+	objects, err := chartLoader.Render(envConfig)
+	if err != nil {
+		return false, fmt.Errorf("failed to render Helm chart: %w", err)
+	}
+
+	changed := false
+	for _, obj := range objects {
+		objDiff, err := diffObject(ctx, cli, obj)
+		if err != nil {
+			return false, err
+		}
+		if objDiff == "" {
+			klog.V(4).InfoS("No differences", "key", objectKey(obj))
+			continue
+		}
+
+		changed = true
+		if _, err := io.WriteString(w, objDiff); err != nil {
+			return false, err
+		}
+	}
+
+	return changed, nil
+}
+
+// diffObject returns the unified diff between the live state of obj and its dry-run apply result
+func diffObject(ctx context.Context, cli client.Client, obj *unstructured.Unstructured) (string, error) {
+	live, err := GetLive(ctx, cli, obj)
+	if err != nil {
+		return "", err
+	}
+
+	merged, err := DryRunApply(ctx, cli, obj)
+	if err != nil {
+		// A namespaced object can't be dry-run applied before its namespace exists,
+		// show the rendered object as is instead
+		if live != nil || !errors.IsNotFound(err) {
+			return "", err
+		}
+		klog.V(4).InfoS("Cannot dry-run apply object, showing the rendered object", "key", objectKey(obj), "err", err)
+		merged = obj
+	}
+
+	from := ""
+	if live != nil {
+		from, err = toYAML(Normalize(live))
+		if err != nil {
+			return "", err
+		}
+	}
+
+	to, err := toYAML(Normalize(merged))
+	if err != nil {
+		return "", err
+	}
+
+	name := objectKey(obj)
+	return diff.Unified("live/"+name, "merged/"+name, from, to), nil
+}
+
+func toYAML(obj *unstructured.Unstructured) (string, error) {
+	data, err := yaml.Marshal(obj.Object)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal object %s to YAML: %w", objectKey(obj), err)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,65 @@
+package deploy
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DryRunStrategy selects whether changes are persisted or only simulated
+type DryRunStrategy string
+
+const (
+	// DryRunNone persists every change
+	DryRunNone DryRunStrategy = "none"
+	// DryRunClient only reports what would be changed, without sending any mutating request
+	DryRunClient DryRunStrategy = "client"
+	// DryRunServer sends every mutating request with dryRun=All, so admission runs without persisting anything
+	DryRunServer DryRunStrategy = "server"
+)
+
+// ParseDryRunStrategy parses the value of a --dry-run flag
+func ParseDryRunStrategy(value string) (DryRunStrategy, error) {
+	switch DryRunStrategy(value) {
+	case "", DryRunNone:
+		return DryRunNone, nil
+	case DryRunClient, DryRunServer:
+		return DryRunStrategy(value), nil
+	default:
+		return "", fmt.Errorf("invalid dry-run value %q, must be one of: none, client, server", value)
+	}
+}
+
+// Enabled returns true if changes must not be persisted
+func (s DryRunStrategy) Enabled() bool {
+	return s == DryRunClient || s == DryRunServer
+}
+
+// suffix returns the marker appended to the reported results, like kubectl does
+func (s DryRunStrategy) suffix() string {
+	if !s.Enabled() {
+		return ""
+	}
+	return fmt.Sprintf(" (%s dry run)", s)
+}
+
+func (s DryRunStrategy) applyOptions() []client.ApplyOption {
+	if s == DryRunServer {
+		return []client.ApplyOption{client.DryRunAll}
+	}
+	return nil
+}
+
+func (s DryRunStrategy) createOptions() []client.CreateOption {
+	if s == DryRunServer {
+		return []client.CreateOption{client.DryRunAll}
+	}
+	return nil
+}
+
+func (s DryRunStrategy) deleteOptions() []client.DeleteOption {
+	if s == DryRunServer {
+		return []client.DeleteOption{client.DryRunAll}
+	}
+	return nil
+}
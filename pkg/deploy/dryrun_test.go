@@ -0,0 +1,29 @@
+package deploy
+
+import "testing"
+
+func TestParseDryRunStrategy(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    DryRunStrategy
+		wantErr bool
+	}{
+		{value: "", want: DryRunNone},
+		{value: "none", want: DryRunNone},
+		{value: "client", want: DryRunClient},
+		{value: "server", want: DryRunServer},
+		{value: "true", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseDryRunStrategy(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDryRunStrategy(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseDryRunStrategy(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package deploy
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/Tal-or/dra-deployer/pkg/helm"
+	"github.com/Tal-or/dra-deployer/pkg/inventory"
+)
+
+// updateInventory records the applied objects and meta in the inventory of release in namespace.
+// When prune is set, the objects of the previous inventory which are no longer
+// rendered are deleted; otherwise they are kept in the inventory, so a later
+// apply --prune or delete still finds them.
+func updateInventory(ctx context.Context, cli client.Client, namespace, release string, objects []*unstructured.Unstructured, meta inventory.Metadata, prune bool, dryRun DryRunStrategy) error {
+	previous, _, err := inventory.Load(ctx, cli, namespace, release)
+	if err != nil {
+		return err
+	}
+
+	current := inventory.FromObjects(objects)
+	stale := inventory.Stale(previous, current)
+
+	saveOpts := append([]client.ApplyOption{client.FieldOwner(FieldManager), client.ForceOwnership}, dryRun.applyOptions()...)
+
+	if !prune {
+		if len(stale) > 0 {
+			klog.InfoS("Objects no longer rendered by the chart are kept, use --prune to delete them", "count", len(stale))
+			for _, entry := range stale {
+				klog.V(2).InfoS("Stale object", "key", entry.String())
+			}
+		}
+		return saveInventory(ctx, cli, namespace, release, inventory.Merge(previous, current), meta, dryRun, saveOpts...)
+	}
+
+	staleObjects := make([]*unstructured.Unstructured, 0, len(stale))
+	for _, entry := range stale {
+		staleObjects = append(staleObjects, entry.Object())
+	}
+	helm.SortByDeleteOrder(staleObjects)
+
+	for _, obj := range staleObjects {
+		if err := deleteObject(ctx, cli, obj, dryRun); err != nil {
+			return err
+		}
+		klog.InfoS("Pruned object"+dryRun.suffix(), "key", objectKey(obj))
+	}
+
+	return saveInventory(ctx, cli, namespace, release, current, meta, dryRun, saveOpts...)
+}
+
+// saveInventory saves the inventory of release, unless running in client dry-run mode
+func saveInventory(ctx context.Context, cli client.Client, namespace, release string, entries []inventory.Entry, meta inventory.Metadata, dryRun DryRunStrategy, opts ...client.ApplyOption) error {
+	if dryRun == DryRunClient {
+		klog.V(2).InfoS("Saved inventory"+dryRun.suffix(), "namespace", namespace, "release", release, "objects", len(entries))
+		return nil
+	}
+	return inventory.Save(ctx, cli, namespace, release, entries, meta, opts...)
+}
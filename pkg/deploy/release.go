@@ -0,0 +1,76 @@
+package deploy
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/Tal-or/dra-deployer/pkg/helm"
+	"github.com/Tal-or/dra-deployer/pkg/params"
+)
+
+// instanceLabel is set by the chart on every object to the release name
+const instanceLabel = "app.kubernetes.io/instance"
+
+// checkReleaseOwnership fails if a cluster-scoped object of objects already exists and belongs
+// to a release other than releaseName. Namespaced objects are isolated by the install namespace.
+func checkReleaseOwnership(ctx context.Context, cli client.Client, objects []*unstructured.Unstructured, releaseName string) error {
+	for _, obj := range objects {
+		if obj.GetNamespace() != "" {
+			continue
+		}
+
+		live, err := GetLive(ctx, cli, obj)
+		if err != nil {
+			return err
+		}
+		if live == nil || ownedByRelease(live, releaseName) {
+			continue
+		}
+
+		owner := live.GetLabels()[instanceLabel]
+		if owner == "" {
+			return fmt.Errorf("%s already exists and was not installed by release %q (use a different --release-name)", objectKey(obj), releaseName)
+		}
+		return fmt.Errorf("%s is owned by release %q (use a different --release-name or change the release values to avoid the name clash)", objectKey(obj), owner)
+	}
+	return nil
+}
+
+// ownedByRelease returns true if obj carries the instance label of releaseName
+func ownedByRelease(obj *unstructured.Unstructured, releaseName string) bool {
+	return obj.GetLabels()[instanceLabel] == releaseName
+}
+
+// checkLegacyRelease fails if the chart is still installed under the release name earlier versions
+// used by default, the chart app version. The default release names its objects differently, so
+// applying it would leave the legacy plugin DaemonSet running next to the new one, and deleting it
+// would not find the legacy objects. Only the default release is checked: an explicit --release-name
+// is the upgrade path, --release-name 0.1.0 keeps managing the legacy install.
+func checkLegacyRelease(ctx context.Context, cli client.Client, chartLoader *helm.ChartLoader, envConfig params.EnvConfig, releaseName string) error {
+	legacy := chartLoader.LegacyReleaseName()
+	if envConfig.ReleaseName != "" || legacy == "" || legacy == releaseName {
+		return nil
+	}
+
+	legacyConfig := envConfig
+	legacyConfig.ReleaseName = legacy
+	objects, err := chartLoader.Render(legacyConfig)
+	if err != nil {
+		return fmt.Errorf("failed to render the legacy release %q: %w", legacy, err)
+	}
+	for _, obj := range objects {
+		live, err := GetLive(ctx, cli, obj)
+		if err != nil {
+			return err
+		}
+		if live != nil && ownedByRelease(live, legacy) {
+			return fmt.Errorf("%s was installed by an earlier version as release %q (run delete --release-name %s first, or pass --release-name %s to keep managing it)",
+				objectKey(obj), legacy, legacy, legacy)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package deploy
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestOwnedByRelease(t *testing.T) {
+	tests := []struct {
+		name    string
+		labels  map[string]string
+		release string
+		want    bool
+	}{
+		{name: "same release", labels: map[string]string{instanceLabel: "staging"}, release: "staging", want: true},
+		{name: "other release", labels: map[string]string{instanceLabel: "canary"}, release: "staging", want: false},
+		{name: "no label", labels: nil, release: "staging", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := &unstructured.Unstructured{}
+			obj.SetLabels(tt.labels)
+			if got := ownedByRelease(obj, tt.release); got != tt.want {
+				t.Errorf("ownedByRelease() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// DefaultWaitTimeout is the default time to wait for the kubelet plugin to roll out or to be deleted
+	DefaultWaitTimeout = 5 * time.Minute
+
+	waitPollInterval = 2 * time.Second
+)
+
+// WaitForDaemonSet blocks until the DaemonSet identified by key has rolled out on all
+// the nodes it is scheduled to, or until timeout expires. While waiting it logs the
+// progress of every node, and on timeout it returns an error naming the pods that are not ready.
+func WaitForDaemonSet(ctx context.Context, cli client.Client, key client.ObjectKey, timeout time.Duration) error {
+	klog.InfoS("Waiting for DaemonSet to roll out", "daemonset", key.String(), "timeout", timeout)
+
+	var pods []corev1.Pod
+	lastProgress := map[string]string{}
+
+	err := wait.PollUntilContextTimeout(ctx, waitPollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+		ds := &appsv1.DaemonSet{}
+		if err := cli.Get(ctx, key, ds); err != nil {
+			return false, fmt.Errorf("failed to get DaemonSet %s: %w", key, err)
+		}
+
+		var err error
+		pods, err = ListDaemonSetPods(ctx, cli, ds)
+		if err != nil {
+			return false, err
+		}
+
+		// Only log the nodes whose state changed since the last poll
+		for _, pod := range pods {
+			progress := podProgress(&pod)
+			if lastProgress[pod.Spec.NodeName] != progress {
+				klog.InfoS("DaemonSet pod progress", "node", pod.Spec.NodeName, "pod", pod.Name, "status", progress)
+				lastProgress[pod.Spec.NodeName] = progress
+			}
+		}
+
+		klog.V(4).InfoS("DaemonSet status", "daemonset", key.String(),
+			"desired", ds.Status.DesiredNumberScheduled,
+			"updated", ds.Status.UpdatedNumberScheduled,
+			"ready", ds.Status.NumberReady)
+
+		return DaemonSetRolledOut(ds), nil
+	})
+	if err != nil {
+		if wait.Interrupted(err) {
+			return fmt.Errorf("timed out after %s waiting for DaemonSet %s to roll out; pods not ready: %s", timeout, key, describeNotReadyPods(pods))
+		}
+		return err
+	}
+
+	klog.InfoS("DaemonSet rolled out", "daemonset", key.String())
+	return nil
+}
+
+// DaemonSetRolledOut returns true once the controller observed the latest spec and
+// every scheduled pod is updated and ready
+func DaemonSetRolledOut(ds *appsv1.DaemonSet) bool {
+	if ds.Status.ObservedGeneration < ds.Generation {
+		return false
+	}
+	desired := ds.Status.DesiredNumberScheduled
+	return ds.Status.UpdatedNumberScheduled == desired && ds.Status.NumberReady == desired
+}
+
+// ListDaemonSetPods returns the pods owned by ds, sorted by node name
+func ListDaemonSetPods(ctx context.Context, cli client.Client, ds *appsv1.DaemonSet) ([]corev1.Pod, error) {
+	selector, err := metav1.LabelSelectorAsSelector(ds.Spec.Selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DaemonSet selector: %w", err)
+	}
+
+	podList := &corev1.PodList{}
+	err = cli.List(ctx, podList, client.InNamespace(ds.Namespace), client.MatchingLabelsSelector{Selector: selector})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list DaemonSet pods: %w", err)
+	}
+
+	var pods []corev1.Pod
+	for _, pod := range podList.Items {
+		if metav1.IsControlledBy(&pod, ds) {
+			pods = append(pods, pod)
+		}
+	}
+
+	sort.Slice(pods, func(i, j int) bool {
+		return pods[i].Spec.NodeName < pods[j].Spec.NodeName
+	})
+	return pods, nil
+}
+
+// podProgress summarizes the state of a DaemonSet pod, e.g. "Running, ready" or "Pending, ImagePullBackOff"
+func podProgress(pod *corev1.Pod) string {
+	if IsPodReady(pod) {
+		return fmt.Sprintf("%s, ready", pod.Status.Phase)
+	}
+	if reason := podNotReadyReason(pod); reason != "" {
+		return fmt.Sprintf("%s, %s", pod.Status.Phase, reason)
+	}
+	return fmt.Sprintf("%s, not ready", pod.Status.Phase)
+}
+
+// describeNotReadyPods lists the pods that are not ready with their node and the reason, if known
+func describeNotReadyPods(pods []corev1.Pod) string {
+	var notReady []string
+	for _, pod := range pods {
+		if IsPodReady(&pod) {
+			continue
+		}
+		desc := fmt.Sprintf("%s (node %s", pod.Name, pod.Spec.NodeName)
+		if reason := podNotReadyReason(&pod); reason != "" {
+			desc += ": " + reason
+		}
+		notReady = append(notReady, desc+")")
+	}
+
+	if len(notReady) == 0 {
+		return "none (the DaemonSet controller did not finish the rollout)"
+	}
+	return strings.Join(notReady, ", ")
+}
+
+// IsPodReady returns true if the pod has the Ready condition set
+func IsPodReady(pod *corev1.Pod) bool {
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodReady {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// podNotReadyReason returns the most relevant reason for a pod not being ready,
+// preferring the state of its containers over the pod conditions
+func podNotReadyReason(pod *corev1.Pod) string {
+	statuses := append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...)
+	statuses = append(statuses, pod.Status.ContainerStatuses...)
+	for _, status := range statuses {
+		if status.State.Waiting != nil && status.State.Waiting.Reason != "" {
+			return status.State.Waiting.Reason
+		}
+		if status.State.Terminated != nil && status.State.Terminated.Reason != "" {
+			return status.State.Terminated.Reason
+		}
+	}
+
+	for _, cond := range pod.Status.Conditions {
+		if cond.Status != corev1.ConditionTrue && cond.Reason != "" {
+			return cond.Reason
+		}
+	}
+	return ""
+}
+
+// WaitForDeletion blocks until none of objects exists anymore, or until timeout expires.
+// While waiting it logs how many objects are left, and on timeout it returns an error naming them.
+func WaitForDeletion(ctx context.Context, cli client.Client, objects []*unstructured.Unstructured, timeout time.Duration) error {
+	klog.InfoS("Waiting for objects to be deleted", "objects", len(objects), "timeout", timeout)
+
+	var remaining []string
+	lastRemaining := -1
+
+	err := wait.PollUntilContextTimeout(ctx, waitPollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+		remaining = remaining[:0]
+		for _, obj := range objects {
+			live, err := GetLive(ctx, cli, obj)
+			if err != nil {
+				return false, err
+			}
+			if live != nil {
+				remaining = append(remaining, objectKey(obj))
+			}
+		}
+
+		if len(remaining) != lastRemaining {
+			klog.InfoS("Objects left to delete", "count", len(remaining))
+			lastRemaining = len(remaining)
+		}
+		return len(remaining) == 0, nil
+	})
+	if err != nil {
+		if wait.Interrupted(err) {
+			return fmt.Errorf("timed out after %s waiting for objects to be deleted: %s", timeout, strings.Join(remaining, ", "))
+		}
+		return err
+	}
+
+	klog.InfoS("All objects deleted")
+	return nil
+}
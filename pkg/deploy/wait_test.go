@@ -0,0 +1,119 @@
+package deploy
+
+import (
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDaemonSetRolledOut(t *testing.T) {
+	tests := []struct {
+		name   string
+		ds     appsv1.DaemonSet
+		expect bool
+	}{
+		{
+			name: "all pods updated and ready",
+			ds: appsv1.DaemonSet{
+				ObjectMeta: metav1.ObjectMeta{Generation: 2},
+				Status: appsv1.DaemonSetStatus{
+					ObservedGeneration:     2,
+					DesiredNumberScheduled: 3,
+					UpdatedNumberScheduled: 3,
+					NumberReady:            3,
+				},
+			},
+			expect: true,
+		},
+		{
+			name: "new generation not observed yet",
+			ds: appsv1.DaemonSet{
+				ObjectMeta: metav1.ObjectMeta{Generation: 3},
+				Status: appsv1.DaemonSetStatus{
+					ObservedGeneration:     2,
+					DesiredNumberScheduled: 3,
+					UpdatedNumberScheduled: 3,
+					NumberReady:            3,
+				},
+			},
+			expect: false,
+		},
+		{
+			name: "rollout in progress",
+			ds: appsv1.DaemonSet{
+				ObjectMeta: metav1.ObjectMeta{Generation: 2},
+				Status: appsv1.DaemonSetStatus{
+					ObservedGeneration:     2,
+					DesiredNumberScheduled: 3,
+					UpdatedNumberScheduled: 1,
+					NumberReady:            3,
+				},
+			},
+			expect: false,
+		},
+		{
+			name: "pods not ready",
+			ds: appsv1.DaemonSet{
+				ObjectMeta: metav1.ObjectMeta{Generation: 2},
+				Status: appsv1.DaemonSetStatus{
+					ObservedGeneration:     2,
+					DesiredNumberScheduled: 3,
+					UpdatedNumberScheduled: 3,
+					NumberReady:            2,
+				},
+			},
+			expect: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DaemonSetRolledOut(&tt.ds); got != tt.expect {
+				t.Errorf("DaemonSetRolledOut() = %v, want %v", got, tt.expect)
+			}
+		})
+	}
+}
+
+func TestDescribeNotReadyPods(t *testing.T) {
+	pods := []corev1.Pod{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "plugin-ready"},
+			Spec:       corev1.PodSpec{NodeName: "node-a"},
+			Status: corev1.PodStatus{
+				Phase:      corev1.PodRunning,
+				Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "plugin-pull"},
+			Spec:       corev1.PodSpec{NodeName: "node-b"},
+			Status: corev1.PodStatus{
+				Phase: corev1.PodPending,
+				ContainerStatuses: []corev1.ContainerStatus{{
+					Name:  "plugin",
+					State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff"}},
+				}},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "plugin-unknown"},
+			Spec:       corev1.PodSpec{NodeName: "node-c"},
+			Status:     corev1.PodStatus{Phase: corev1.PodPending},
+		},
+	}
+
+	want := "plugin-pull (node node-b: ImagePullBackOff), plugin-unknown (node node-c)"
+	if got := describeNotReadyPods(pods); got != want {
+		t.Errorf("describeNotReadyPods() = %q, want %q", got, want)
+	}
+
+	if got := podProgress(&pods[0]); got != "Running, ready" {
+		t.Errorf("podProgress() = %q, want %q", got, "Running, ready")
+	}
+	if got := podProgress(&pods[1]); got != "Pending, ImagePullBackOff" {
+		t.Errorf("podProgress() = %q, want %q", got, "Pending, ImagePullBackOff")
+	}
+}
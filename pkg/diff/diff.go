@@ -0,0 +1,165 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// contextLines is the number of unchanged lines shown around each change, like diff -u
+const contextLines = 3
+
+type opKind int
+
+const (
+	opEqual opKind = iota
+	opDelete
+	opInsert
+)
+
+type op struct {
+	kind opKind
+	line string
+}
+
+// Unified returns the unified diff turning from into to, using fromName and toName
+// as file names in the header. It returns an empty string if the texts are equal.
+func Unified(fromName, toName, from, to string) string {
+	if from == to {
+		return ""
+	}
+
+	ops := lineOps(splitLines(from), splitLines(to))
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", fromName, toName)
+	for _, h := range hunks(ops) {
+		writeHunk(&sb, ops, h)
+	}
+	return sb.String()
+}
+
+// splitLines splits s into lines, keeping the line terminators
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// lineOps computes the edit script between a and b from their longest common subsequence
+func lineOps(a, b []string) []op {
+	// lcs[i][j] is the length of the LCS of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var ops []op
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, op{kind: opEqual, line: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, op{kind: opDelete, line: a[i]})
+			i++
+		default:
+			ops = append(ops, op{kind: opInsert, line: b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, op{kind: opDelete, line: a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, op{kind: opInsert, line: b[j]})
+	}
+	return ops
+}
+
+// hunk is a range [start, end) of ops
+type hunk struct {
+	start, end int
+}
+
+// hunks groups the changes of ops with their surrounding context, merging
+// changes which are closer than twice the context size
+func hunks(ops []op) []hunk {
+	var result []hunk
+	for i, o := range ops {
+		if o.kind == opEqual {
+			continue
+		}
+		start := max(i-contextLines, 0)
+		end := min(i+1+contextLines, len(ops))
+		if n := len(result); n > 0 && start <= result[n-1].end {
+			result[n-1].end = end
+			continue
+		}
+		result = append(result, hunk{start: start, end: end})
+	}
+	return result
+}
+
+func writeHunk(sb *strings.Builder, ops []op, h hunk) {
+	// Line numbers are 1-based and count the lines preceding the hunk on each side
+	fromLine, toLine := 1, 1
+	for _, o := range ops[:h.start] {
+		if o.kind != opInsert {
+			fromLine++
+		}
+		if o.kind != opDelete {
+			toLine++
+		}
+	}
+
+	fromCount, toCount := 0, 0
+	var body strings.Builder
+	for _, o := range ops[h.start:h.end] {
+		line := o.line
+		if !strings.HasSuffix(line, "\n") {
+			line += "\n"
+		}
+		switch o.kind {
+		case opEqual:
+			fromCount++
+			toCount++
+			body.WriteString(" " + line)
+		case opDelete:
+			fromCount++
+			body.WriteString("-" + line)
+		case opInsert:
+			toCount++
+			body.WriteString("+" + line)
+		}
+	}
+
+	fmt.Fprintf(sb, "@@ -%s +%s @@\n", hunkRange(fromLine, fromCount), hunkRange(toLine, toCount))
+	sb.WriteString(body.String())
+}
+
+// hunkRange formats a hunk range like GNU diff, where an empty range refers to the line before it
+func hunkRange(line, count int) string {
+	if count == 0 {
+		line--
+	}
+	if count == 1 {
+		return fmt.Sprintf("%d", line)
+	}
+	return fmt.Sprintf("%d,%d", line, count)
+}
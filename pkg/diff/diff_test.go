@@ -0,0 +1,77 @@
+package diff
+
+import (
+	"testing"
+)
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		name string
+		from string
+		to   string
+		want string
+	}{
+		{
+			name: "equal",
+			from: "a\nb\n",
+			to:   "a\nb\n",
+			want: "",
+		},
+		{
+			name: "new object",
+			from: "",
+			to:   "kind: ServiceAccount\nname: sa\n",
+			want: `--- live
++++ merged
+@@ -0,0 +1,2 @@
++kind: ServiceAccount
++name: sa
+`,
+		},
+		{
+			name: "changed line with context",
+			from: "a\nb\nc\nd\ne\nf\ng\nh\n",
+			to:   "a\nb\nc\nd\nE\nf\ng\nh\n",
+			want: `--- live
++++ merged
+@@ -2,7 +2,7 @@
+ b
+ c
+ d
+-e
++E
+ f
+ g
+ h
+`,
+		},
+		{
+			name: "separate hunks",
+			from: "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+			to:   "one\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n",
+			want: `--- live
++++ merged
+@@ -1,4 +1,4 @@
+-1
++one
+ 2
+ 3
+ 4
+@@ -9,4 +9,3 @@
+ 9
+ 10
+ 11
+-12
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Unified("live", "merged", tt.from, tt.to)
+			if got != tt.want {
+				t.Errorf("Unified() =\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
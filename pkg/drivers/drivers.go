@@ -0,0 +1,131 @@
+package drivers
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/chartutil"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/Tal-or/dra-deployer/pkg/image"
+)
+
+// Default is the name of the driver deployed when none is selected
+const Default = "memory"
+
+// Driver describes a DRA driver bundled with the deployer
+type Driver struct {
+	Name        string // Name selects the driver with --driver
+	Description string
+	ChartDir    string // ChartDir is the directory of the driver's Helm chart inside the embedded assets
+	Image       string // Image is the default container image of the kubelet plugin
+	Command     string // Command is the default command of the kubelet plugin container
+	Values      string // Values holds the driver's default chart values as YAML, layered over the chart's values.yaml
+}
+
+// registry lists the bundled drivers. Only the memory driver has its own chart, the cpu and
+// example drivers reuse it and set their name, driver identifier, devices and host mounts
+// through the chart values.
+var registry = []Driver{
+	{
+		Name:        "memory",
+		Description: "DRA driver exposing memory devices (dra-driver-memory)",
+		ChartDir:    "deployment/helm/dra-driver-memory",
+		Image:       "quay.io/fromani/dra-driver-memory:v0.0.2025112401",
+		Command:     "/bin/dramemory",
+	},
+	{
+		Name:        "cpu",
+		Description: "DRA driver exposing exclusive CPUs (dra-driver-cpu)",
+		ChartDir:    "deployment/helm/dra-driver-memory",
+		Image:       "quay.io/titzhak/dra-cpu-driver:latest",
+		Command:     "/bin/dracpu",
+		Values: `
+nameOverride: dra-driver-cpu
+driver:
+  name: dra.cpu
+daemonset:
+  env:
+    # the CPUs are discovered on the node, not generated
+    numDevices: ""
+`,
+	},
+	{
+		Name:        "example",
+		Description: "Kubernetes DRA example driver exposing mock GPUs (dra-example-driver)",
+		ChartDir:    "deployment/helm/dra-driver-memory",
+		Image:       "quay.io/titzhak/dra-example-driver:v0.1.0",
+		Command:     "dra-example-kubeletplugin",
+		Values: `
+nameOverride: dra-example-driver
+driver:
+  name: gpu.example.com
+daemonset:
+  volumes:
+    # the example driver is no NRI plugin
+    nri: ""
+`,
+	},
+}
+
+// Chart returns the name of the driver's chart
+func (d Driver) Chart() string {
+	return path.Base(d.ChartDir)
+}
+
+// Get returns the bundled driver called name
+func Get(name string) (Driver, error) {
+	for _, d := range registry {
+		if d.Name == name {
+			return d, nil
+		}
+	}
+	return Driver{}, fmt.Errorf("unknown driver %q, must be one of: %s", name, strings.Join(Names(), ", "))
+}
+
+// List returns all the bundled drivers sorted by name
+func List() []Driver {
+	list := append([]Driver{}, registry...)
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// Names returns the names of all the bundled drivers sorted alphabetically
+func Names() []string {
+	var names []string
+	for _, d := range List() {
+		names = append(names, d.Name)
+	}
+	return names
+}
+
+// DefaultValues returns a fresh copy of the driver's default chart values, including its image
+// and command. They are layered below the values given on the command line, so -f and --set can
+// still override the image and the command of the driver.
+func (d Driver) DefaultValues() (map[string]any, error) {
+	values := map[string]any{}
+	if err := yaml.Unmarshal([]byte(d.Values), &values); err != nil {
+		return nil, fmt.Errorf("failed to parse default values of driver %s: %w", d.Name, err)
+	}
+
+	ref, err := image.Parse(d.Image)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse default image of driver %s: %w", d.Name, err)
+	}
+	defaults := map[string]any{
+		"image": map[string]any{
+			"repository": ref.Image,
+			"tag":        ref.Tag,
+			"digest":     ref.Digest,
+		},
+		"daemonset": map[string]any{
+			"command": []any{d.Command},
+		},
+	}
+	return chartutil.MergeTables(values, defaults), nil
+}
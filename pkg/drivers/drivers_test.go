@@ -0,0 +1,174 @@
+package drivers
+
+import (
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chartutil"
+
+	"github.com/Tal-or/dra-deployer/pkg/helm"
+	"github.com/Tal-or/dra-deployer/pkg/params"
+)
+
+func TestGet(t *testing.T) {
+	d, err := Get(Default)
+	if err != nil {
+		t.Fatalf("Get(%q) failed: %v", Default, err)
+	}
+	if d.Name != Default {
+		t.Errorf("Get(%q).Name = %q", Default, d.Name)
+	}
+
+	if _, err := Get("nonexistent"); err == nil {
+		t.Error("Get() expected error for unknown driver, got nil")
+	}
+}
+
+func TestRegistryDriversRender(t *testing.T) {
+	for _, d := range List() {
+		t.Run(d.Name, func(t *testing.T) {
+			if d.Image == "" || d.Command == "" || d.ChartDir == "" {
+				t.Fatalf("driver %s must set a chart, an image and a command", d.Name)
+			}
+
+			values, err := d.DefaultValues()
+			if err != nil {
+				t.Fatalf("DefaultValues() failed: %v", err)
+			}
+
+			loader, err := helm.NewChartLoaderForConfig(params.EnvConfig{ChartDir: d.ChartDir})
+			if err != nil {
+				t.Fatalf("Failed to load chart of driver %s: %v", d.Name, err)
+			}
+
+			envConfig := params.EnvConfig{
+				Namespace: "test-namespace",
+				Values:    values,
+			}
+
+			objects, err := loader.Render(envConfig)
+			if err != nil {
+				t.Fatalf("Failed to render chart of driver %s: %v", d.Name, err)
+			}
+			if len(objects) == 0 {
+				t.Fatalf("Expected driver %s to render objects", d.Name)
+			}
+
+			img, err := loader.Image(envConfig)
+			if err != nil {
+				t.Fatalf("Image() failed: %v", err)
+			}
+			if img.String() != d.Image {
+				t.Errorf("Driver %s renders image %q, want %q", d.Name, img.String(), d.Image)
+			}
+
+			driverName, err := loader.DriverName(envConfig)
+			if err != nil {
+				t.Fatalf("DriverName() failed: %v", err)
+			}
+			t.Logf("Driver %s renders %d objects for %s", d.Name, len(objects), driverName)
+		})
+	}
+}
+
+func TestDefaultValuesAreCopies(t *testing.T) {
+	d, err := Get("cpu")
+	if err != nil {
+		t.Fatalf("Get() failed: %v", err)
+	}
+
+	first, err := d.DefaultValues()
+	if err != nil {
+		t.Fatalf("DefaultValues() failed: %v", err)
+	}
+	first["driver"].(map[string]any)["name"] = "changed"
+
+	second, err := d.DefaultValues()
+	if err != nil {
+		t.Fatalf("DefaultValues() failed: %v", err)
+	}
+	if got := second["driver"].(map[string]any)["name"]; got != "dra.cpu" {
+		t.Errorf("DefaultValues() returned shared maps, driver.name = %v", got)
+	}
+}
+
+func TestUserValuesOverrideDriverDefaults(t *testing.T) {
+	d, err := Get(Default)
+	if err != nil {
+		t.Fatalf("Get() failed: %v", err)
+	}
+	values, err := d.DefaultValues()
+	if err != nil {
+		t.Fatalf("DefaultValues() failed: %v", err)
+	}
+
+	loader, err := helm.NewChartLoaderForConfig(params.EnvConfig{ChartDir: d.ChartDir})
+	if err != nil {
+		t.Fatalf("Failed to load chart of driver %s: %v", d.Name, err)
+	}
+
+	// Like --set image.tag=foo, layered over the driver defaults
+	user := map[string]any{"image": map[string]any{"tag": "foo"}}
+	img, err := loader.Image(params.EnvConfig{Values: chartutil.MergeTables(user, values)})
+	if err != nil {
+		t.Fatalf("Image() failed: %v", err)
+	}
+	if want := "quay.io/fromani/dra-driver-memory:foo"; img.String() != want {
+		t.Errorf("Image() = %q, want %q", img.String(), want)
+	}
+}
+
+func TestDriverDaemonSet(t *testing.T) {
+	tests := []struct {
+		driver     string
+		numDevices bool
+		nri        bool
+	}{
+		{driver: "memory", numDevices: true, nri: true},
+		{driver: "cpu", numDevices: false, nri: true},
+		{driver: "example", numDevices: true, nri: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.driver, func(t *testing.T) {
+			d, err := Get(tt.driver)
+			if err != nil {
+				t.Fatalf("Get() failed: %v", err)
+			}
+			values, err := d.DefaultValues()
+			if err != nil {
+				t.Fatalf("DefaultValues() failed: %v", err)
+			}
+			loader, err := helm.NewChartLoaderForConfig(params.EnvConfig{ChartDir: d.ChartDir})
+			if err != nil {
+				t.Fatalf("Failed to load chart of driver %s: %v", d.Name, err)
+			}
+
+			objects, _, err := loader.RenderTemplates(params.EnvConfig{Namespace: "test-namespace", Values: values}, []string{"templates/daemonset.yaml"})
+			if err != nil {
+				t.Fatalf("Failed to render chart of driver %s: %v", d.Name, err)
+			}
+			spec := objects[0].Object["spec"].(map[string]any)["template"].(map[string]any)["spec"].(map[string]any)
+			container := spec["containers"].([]any)[0].(map[string]any)
+
+			numDevices := false
+			for _, env := range container["env"].([]any) {
+				if env.(map[string]any)["name"] == "NUM_DEVICES" {
+					numDevices = true
+				}
+			}
+			if numDevices != tt.numDevices {
+				t.Errorf("NUM_DEVICES set = %v, want %v", numDevices, tt.numDevices)
+			}
+
+			nri := false
+			for _, volume := range spec["volumes"].([]any) {
+				if volume.(map[string]any)["name"] == "nri" {
+					nri = true
+				}
+			}
+			if nri != tt.nri {
+				t.Errorf("NRI socket mounted = %v, want %v", nri, tt.nri)
+			}
+		})
+	}
+}
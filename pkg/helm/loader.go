@@ -4,9 +4,13 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"io/fs"
+	"path"
+	"sort"
 	"strings"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/yaml"
 	"k8s.io/klog/v2"
 
@@ -14,7 +18,9 @@ import (
 	"helm.sh/helm/v3/pkg/chart/loader"
 	"helm.sh/helm/v3/pkg/chartutil"
 	"helm.sh/helm/v3/pkg/engine"
+	"helm.sh/helm/v3/pkg/ignore"
 
+	"github.com/Tal-or/dra-deployer/assets"
 	"github.com/Tal-or/dra-deployer/pkg/image"
 	"github.com/Tal-or/dra-deployer/pkg/params"
 
@@ -22,8 +28,8 @@ import (
 )
 
 const (
-	// DefaultChartPath is the default path to the Helm chart directory
-	DefaultChartPath = "assets/deployment/helm/dra-driver-memory"
+	// DefaultChartDir is the directory of the default Helm chart inside the embedded assets
+	DefaultChartDir = "deployment/helm/dra-driver-memory"
 )
 
 // ChartLoader loads and renders Helm charts
@@ -31,11 +37,12 @@ type ChartLoader struct {
 	chart *chart.Chart
 }
 
-// NewChartLoader creates a new ChartLoader by loading the Helm chart from the filesystem
-// If chartPath is empty, it uses DefaultChartPath
+// NewChartLoader creates a new ChartLoader.
+// If chartPath is empty, the chart embedded in the binary (DefaultChartDir) is used,
+// otherwise the chart is loaded from chartPath on the filesystem.
 func NewChartLoader(chartPath string) (*ChartLoader, error) {
 	if chartPath == "" {
-		chartPath = DefaultChartPath
+		return NewChartLoaderFromFS(assets.HelmCharts, DefaultChartDir)
 	}
 
 	klog.V(4).InfoS("Loading Helm chart from filesystem", "path", chartPath)
@@ -53,28 +60,61 @@ func NewChartLoader(chartPath string) (*ChartLoader, error) {
 	}, nil
 }
 
-// Render renders the Helm chart with the given options and returns Kubernetes objects
-func (l *ChartLoader) Render(envConfig params.EnvConfig) ([]*unstructured.Unstructured, error) {
-	releaseName := l.chart.Metadata.AppVersion
-	klog.V(4).InfoS("Rendering Helm chart", "release", releaseName, "namespace", envConfig.Namespace)
+// NewChartLoaderForConfig creates a new ChartLoader for the chart selected by envConfig:
+// the chart directory at envConfig.ChartPath if set, otherwise the embedded chart envConfig.ChartDir
+func NewChartLoaderForConfig(envConfig params.EnvConfig) (*ChartLoader, error) {
+	if envConfig.ChartPath != "" {
+		return NewChartLoader(envConfig.ChartPath)
+	}
+	chartDir := envConfig.ChartDir
+	if chartDir == "" {
+		chartDir = DefaultChartDir
+	}
+	return NewChartLoaderFromFS(assets.HelmCharts, chartDir)
+}
 
-	// Start with default values from values.yaml
-	values := l.chart.Values
+// NewChartLoaderFromFS creates a new ChartLoader by loading the Helm chart
+// stored under dir in fsys, honoring the chart's .helmignore file
+func NewChartLoaderFromFS(fsys fs.FS, dir string) (*ChartLoader, error) {
+	klog.V(4).InfoS("Loading Helm chart from embedded files", "dir", dir)
 
-	// Build runtime values from envConfig
-	runtimeValues, err := buildValuesFromEnvConfig(envConfig)
+	files, err := readChartFiles(fsys, dir)
 	if err != nil {
-		return nil, fmt.Errorf("failed to build values from envConfig: %w", err)
+		return nil, fmt.Errorf("failed to read Helm chart files from %s: %w", dir, err)
 	}
 
-	// Merge runtime values with defaults (runtime values take precedence)
-	if len(runtimeValues) > 0 {
-		values = chartutil.CoalesceTables(runtimeValues, values)
+	chart, err := loader.LoadFiles(files)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load Helm chart from %s: %w", dir, err)
 	}
 
-	// Merge any additional custom values provided
-	if envConfig.Values != nil {
-		values = chartutil.CoalesceTables(envConfig.Values, values)
+	klog.V(4).InfoS("Loaded Helm chart", "name", chart.Name(), "version", chart.Metadata.Version)
+
+	return &ChartLoader{
+		chart: chart,
+	}, nil
+}
+
+// Render renders the Helm chart with the given options and returns Kubernetes objects
+func (l *ChartLoader) Render(envConfig params.EnvConfig) ([]*unstructured.Unstructured, error) {
+	objects, _, err := l.RenderTemplates(envConfig, nil)
+	return objects, err
+}
+
+// RenderTemplates renders the Helm chart like Render, but only returns the objects of the given
+// templates, named by their path in the chart (e.g. templates/daemonset.yaml). All objects are
+// returned when templates is empty. It also returns the container images pointed at their registry
+// mirror.
+func (l *ChartLoader) RenderTemplates(envConfig params.EnvConfig, templates []string) ([]*unstructured.Unstructured, []ImageRewrite, error) {
+	releaseName, err := l.ReleaseName(envConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	klog.V(4).InfoS("Rendering Helm chart", "release", releaseName, "namespace", envConfig.Namespace)
+
+	values, err := l.Values(envConfig)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	// Set up release options
@@ -87,23 +127,139 @@ func (l *ChartLoader) Render(envConfig params.EnvConfig) ([]*unstructured.Unstru
 	// Convert values to renderable format
 	valuesToRender, err := chartutil.ToRenderValues(l.chart, values, releaseOptions, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to prepare values for rendering: %w", err)
+		return nil, nil, fmt.Errorf("failed to prepare values for rendering: %w", err)
 	}
 
 	// Render templates
 	rendered, err := engine.Render(l.chart, valuesToRender)
 	if err != nil {
-		return nil, fmt.Errorf("failed to render templates: %w", err)
+		return nil, nil, fmt.Errorf("failed to render templates: %w", err)
+	}
+
+	if len(templates) > 0 {
+		rendered, err = selectTemplates(rendered, l.chart.Name(), templates)
+		if err != nil {
+			return nil, nil, err
+		}
 	}
 
 	// Convert rendered YAML to Kubernetes objects
 	objects, err := parseRenderedTemplates(rendered)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse rendered templates: %w", err)
+		return nil, nil, fmt.Errorf("failed to parse rendered templates: %w", err)
+	}
+
+	// Point every container image at its registry mirror, the plugin image included: the values
+	// always hold the source image, so each image is rewritten exactly once
+	rewrites, err := rewriteContainerImages(objects, envConfig.RegistryMirrors)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	klog.V(4).InfoS("Successfully rendered Helm chart", "objectCount", len(objects))
-	return objects, nil
+	return objects, rewrites, nil
+}
+
+// Values returns the values the chart is rendered with: the chart defaults from values.yaml,
+// overridden by envConfig.Values, overridden by the typed envConfig settings which are set
+func (l *ChartLoader) Values(envConfig params.EnvConfig) (map[string]any, error) {
+	// Start with default values from values.yaml
+	values := l.chart.Values
+
+	// Build runtime values from envConfig
+	runtimeValues, err := buildValuesFromEnvConfig(envConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build values from envConfig: %w", err)
+	}
+
+	// Merge any additional custom values provided (custom values take precedence over defaults).
+	// MergeTables keeps null values, which remove the chart defaults once the engine coalesces the
+	// values with the chart. It modifies its destination, so merge into a copy of the custom values:
+	// the values are built more than once per render.
+	if envConfig.Values != nil {
+		values = chartutil.MergeTables(runtime.DeepCopyJSON(envConfig.Values), values)
+	}
+
+	// Merge runtime values last, so typed settings like the image take precedence over custom values
+	if len(runtimeValues) > 0 {
+		values = chartutil.MergeTables(runtimeValues, values)
+	}
+
+	return values, nil
+}
+
+// ReleaseName returns the Helm release name the chart is rendered with: envConfig.ReleaseName, or the
+// chart name (.Values.nameOverride if set) by default. The names of the cluster-scoped objects are
+// derived from it, so independent installs in one cluster need different release names.
+func (l *ChartLoader) ReleaseName(envConfig params.EnvConfig) (string, error) {
+	name := envConfig.ReleaseName
+	if name == "" {
+		values, err := l.Values(envConfig)
+		if err != nil {
+			return "", err
+		}
+		name, _ = values["nameOverride"].(string)
+		if name == "" {
+			name = l.chart.Name()
+		}
+	}
+
+	if err := chartutil.ValidateReleaseName(name); err != nil {
+		return "", fmt.Errorf("invalid release name %q: %w", name, err)
+	}
+	return name, nil
+}
+
+// LegacyReleaseName returns the release name earlier versions of the deployer rendered the chart with
+// when none was given: the chart app version, e.g. "0.1.0"
+func (l *ChartLoader) LegacyReleaseName() string {
+	return l.chart.AppVersion()
+}
+
+// DriverName returns the DRA driver name (.Values.driver.name) the chart is rendered with
+func (l *ChartLoader) DriverName(envConfig params.EnvConfig) (string, error) {
+	values, err := l.Values(envConfig)
+	if err != nil {
+		return "", err
+	}
+
+	driver, ok := values["driver"].(map[string]any)
+	if !ok {
+		return "", fmt.Errorf("chart values have no driver section")
+	}
+	name, ok := driver["name"].(string)
+	if !ok || name == "" {
+		return "", fmt.Errorf("chart values have no driver.name")
+	}
+	return name, nil
+}
+
+// Image returns the plugin image the chart is rendered with: envConfig.Image if set, otherwise
+// the image.repository, image.tag and image.digest values
+func (l *ChartLoader) Image(envConfig params.EnvConfig) (image.Reference, error) {
+	if envConfig.Image != "" {
+		return image.Parse(envConfig.Image)
+	}
+
+	values, err := l.Values(envConfig)
+	if err != nil {
+		return image.Reference{}, err
+	}
+	img, ok := values["image"].(map[string]any)
+	if !ok {
+		return image.Reference{}, fmt.Errorf("chart values have no image section")
+	}
+	ref := image.Reference{}
+	ref.Image, _ = img["repository"].(string)
+	if ref.Image == "" {
+		return image.Reference{}, fmt.Errorf("chart values have no image.repository")
+	}
+	// Tags like 1.0 are parsed as numbers from YAML
+	if tag, ok := img["tag"]; ok && tag != nil {
+		ref.Tag = fmt.Sprint(tag)
+	}
+	ref.Digest, _ = img["digest"].(string)
+	return image.Parse(ref.String())
 }
 
 // GetChart returns the loaded Helm chart
@@ -117,7 +273,7 @@ func buildValuesFromEnvConfig(envConfig params.EnvConfig) (map[string]any, error
 
 	// Set image if provided
 	if envConfig.Image != "" {
-		// Parse image into repository and tag
+		// Parse image into repository, tag and digest
 		ref, err := image.Parse(envConfig.Image)
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse image: %w", err)
@@ -125,16 +281,42 @@ func buildValuesFromEnvConfig(envConfig params.EnvConfig) (map[string]any, error
 		values["image"] = map[string]any{
 			"repository": ref.Image,
 			"tag":        ref.Tag,
+			"digest":     ref.Digest,
 		}
-		klog.V(5).InfoS("Set image reference from envConfig", "envConfig.Image", envConfig.Image, "image", ref.Image, "tag", ref.Tag)
+		klog.V(5).InfoS("Set image reference from envConfig", "envConfig.Image", envConfig.Image, "image", ref.Image, "tag", ref.Tag, "digest", ref.Digest)
 	}
 
-	// Set OpenShift flag
+	// Set the image pull secrets if provided
+	if len(envConfig.ImagePullSecrets) > 0 {
+		secrets := make([]any, 0, len(envConfig.ImagePullSecrets))
+		for _, name := range envConfig.ImagePullSecrets {
+			secrets = append(secrets, map[string]any{"name": name})
+		}
+		values["imagePullSecrets"] = secrets
+		klog.V(5).InfoS("Set image pull secrets from envConfig", "secrets", envConfig.ImagePullSecrets)
+	}
+	if envConfig.RegistryAuth != "" {
+		values["registryAuth"] = map[string]any{
+			"dockerConfigJSON": envConfig.RegistryAuth,
+		}
+		klog.V(5).InfoS("Set registry credentials from envConfig")
+	}
+
+	// Set OpenShift flag, hosted control planes run the same SCC admission. The platform is always
+	// known (detected or --platform), so it overrides openshift.enabled from the chart values
 	values["openshift"] = map[string]any{
-		"enabled": envConfig.Platform == platform.OpenShift,
+		"enabled": envConfig.Platform == platform.OpenShift || envConfig.Platform == platform.HyperShift,
 	}
 	klog.V(5).InfoS("Platform detected", "platform", envConfig.Platform)
 
+	// Set the resource.k8s.io versions if known
+	if len(envConfig.ResourceAPIVersions) > 0 {
+		values["resourceAPI"] = map[string]any{
+			"versions": envConfig.ResourceAPIVersions,
+		}
+		klog.V(5).InfoS("Set resource.k8s.io versions from envConfig", "versions", envConfig.ResourceAPIVersions)
+	}
+
 	// Build daemonset configuration
 	daemonsetValues := make(map[string]any)
 
@@ -158,11 +340,37 @@ func buildValuesFromEnvConfig(envConfig params.EnvConfig) (map[string]any, error
 	return values, nil
 }
 
+// selectTemplates returns the rendered templates named in templates. The rendered templates are
+// keyed by their path prefixed with the chart name, templates are given relative to the chart.
+func selectTemplates(rendered map[string]string, chartName string, templates []string) (map[string]string, error) {
+	selected := make(map[string]string, len(templates))
+	for _, tmpl := range templates {
+		key := path.Join(chartName, path.Clean(tmpl))
+		content, ok := rendered[key]
+		if !ok {
+			return nil, fmt.Errorf("could not find template %s in chart", tmpl)
+		}
+		if strings.TrimSpace(content) == "" {
+			return nil, fmt.Errorf("template %s rendered no objects with the given values", tmpl)
+		}
+		selected[key] = content
+	}
+	return selected, nil
+}
+
 // parseRenderedTemplates converts rendered YAML templates to Kubernetes objects
 func parseRenderedTemplates(rendered map[string]string) ([]*unstructured.Unstructured, error) {
 	var objects []*unstructured.Unstructured
 
-	for name, content := range rendered {
+	// Walk the templates in name order, map iteration order is random
+	names := make([]string, 0, len(rendered))
+	for name := range rendered {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		content := rendered[name]
 		// Skip non-YAML files (like NOTES.txt, helpers.tpl, etc.)
 		if !strings.HasSuffix(name, ".yaml") && !strings.HasSuffix(name, ".yml") {
 			klog.V(6).InfoS("Skipping non-YAML file", "name", name)
@@ -186,6 +394,7 @@ func parseRenderedTemplates(rendered map[string]string) ([]*unstructured.Unstruc
 		klog.V(5).InfoS("Parsed template", "name", name, "objects", len(objs))
 	}
 
+	SortByInstallOrder(objects)
 	return objects, nil
 }
 
@@ -215,3 +424,57 @@ func parseYAMLDocuments(content string) ([]*unstructured.Unstructured, error) {
 
 	return objects, nil
 }
+
+// readChartFiles collects the chart files under dir in fsys, skipping the ones
+// matched by the chart's .helmignore rules
+func readChartFiles(fsys fs.FS, dir string) ([]*loader.BufferedFile, error) {
+	rules := ignore.Empty()
+	if data, err := fs.ReadFile(fsys, path.Join(dir, ignore.HelmIgnore)); err == nil {
+		rules, err = ignore.Parse(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", ignore.HelmIgnore, err)
+		}
+	}
+	rules.AddDefaults()
+
+	var files []*loader.BufferedFile
+	err := fs.WalkDir(fsys, dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel := strings.TrimPrefix(strings.TrimPrefix(name, dir), "/")
+		if rel == "" {
+			return nil
+		}
+
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		if rules.Ignore(rel, fi) {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		if d.IsDir() {
+			return nil
+		}
+
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", rel, err)
+		}
+
+		files = append(files, &loader.BufferedFile{Name: rel, Data: data})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return files, nil
+}
@@ -1,9 +1,13 @@
 package helm
 
 import (
+	"encoding/base64"
 	"path/filepath"
+	"reflect"
 	"testing"
 
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
 	"github.com/k8stopologyawareschedwg/deployer/pkg/deployer/platform"
 
 	"github.com/Tal-or/dra-deployer/pkg/image"
@@ -131,6 +135,391 @@ func TestRenderWithoutOpenShift(t *testing.T) {
 	t.Log("SCC correctly not created when platform is not OpenShift")
 }
 
+func TestRenderEnvConfigOverridesValues(t *testing.T) {
+	chartPath := filepath.Join("..", "..", "assets", "deployment", "helm", "dra-driver-memory")
+	loader, err := NewChartLoader(chartPath)
+	if err != nil {
+		t.Fatalf("Failed to create chart loader: %v", err)
+	}
+
+	// Custom values set both the image and the pull policy, the typed image setting must win
+	envConfig := params.EnvConfig{
+		Namespace: "test-namespace",
+		Image:     "quay.io/myorg/dra-memory-driver:v0.5.0",
+		Values: map[string]any{
+			"image": map[string]any{
+				"repository": "quay.io/other/driver",
+				"tag":        "v9.9.9",
+				"pullPolicy": "IfNotPresent",
+			},
+		},
+	}
+
+	objects, err := loader.Render(envConfig)
+	if err != nil {
+		t.Fatalf("Failed to render chart: %v", err)
+	}
+
+	foundDaemonSet := false
+	for _, obj := range objects {
+		if obj.GetKind() != "DaemonSet" {
+			continue
+		}
+		foundDaemonSet = true
+
+		containers, found, err := getNestedSlice(obj.Object, "spec", "template", "spec", "containers")
+		if err != nil || !found || len(containers) == 0 {
+			t.Fatalf("Failed to get containers from DaemonSet: %v", err)
+		}
+		container := containers[0].(map[string]interface{})
+
+		if got := container["image"]; got != "quay.io/myorg/dra-memory-driver:v0.5.0" {
+			t.Errorf("Expected image from envConfig to take precedence, got %q", got)
+		}
+		if got := container["imagePullPolicy"]; got != "IfNotPresent" {
+			t.Errorf("Expected imagePullPolicy from custom values, got %q", got)
+		}
+	}
+
+	if !foundDaemonSet {
+		t.Error("DaemonSet not found in rendered objects")
+	}
+}
+
+func TestRenderNullValue(t *testing.T) {
+	loader, err := NewChartLoader("")
+	if err != nil {
+		t.Fatalf("Failed to create chart loader: %v", err)
+	}
+
+	// --set daemonset.env.numDevices=null removes the chart default
+	envConfig := params.EnvConfig{
+		Namespace: "test-namespace",
+		Values: map[string]any{
+			"daemonset": map[string]any{
+				"env": map[string]any{"numDevices": nil},
+			},
+		},
+	}
+
+	// Render builds the values more than once, the null value must survive every time
+	for i := 0; i < 2; i++ {
+		objects, _, err := loader.RenderTemplates(envConfig, []string{"templates/daemonset.yaml"})
+		if err != nil {
+			t.Fatalf("Failed to render chart: %v", err)
+		}
+		containers, found, err := getNestedSlice(objects[0].Object, "spec", "template", "spec", "containers")
+		if err != nil || !found || len(containers) == 0 {
+			t.Fatalf("Failed to get containers from DaemonSet: %v", err)
+		}
+		env, _ := containers[0].(map[string]interface{})["env"].([]interface{})
+		for _, e := range env {
+			if e.(map[string]interface{})["name"] == "NUM_DEVICES" {
+				t.Errorf("Expected NUM_DEVICES to be removed by the null value, got %v", e)
+			}
+		}
+	}
+
+	numDevices, found, _ := unstructured.NestedFieldNoCopy(envConfig.Values, "daemonset", "env", "numDevices")
+	if !found || numDevices != nil {
+		t.Errorf("Expected the null value to be kept in envConfig.Values, got %v (found %v)", numDevices, found)
+	}
+}
+
+func TestRenderValuesWithoutTypedSettings(t *testing.T) {
+	loader, err := NewChartLoader("")
+	if err != nil {
+		t.Fatalf("Failed to create chart loader: %v", err)
+	}
+
+	// Without --image and --command, the image and command values are rendered
+	envConfig := params.EnvConfig{
+		Namespace: "test-namespace",
+		Values: map[string]any{
+			"image": map[string]any{
+				"repository": "quay.io/myorg/dra-memory-driver",
+				"tag":        "foo",
+			},
+			"daemonset": map[string]any{
+				"command": []any{"/bin/custom"},
+			},
+		},
+	}
+
+	objects, _, err := loader.RenderTemplates(envConfig, []string{"templates/daemonset.yaml"})
+	if err != nil {
+		t.Fatalf("Failed to render chart: %v", err)
+	}
+	containers, found, err := getNestedSlice(objects[0].Object, "spec", "template", "spec", "containers")
+	if err != nil || !found || len(containers) == 0 {
+		t.Fatalf("Failed to get containers from DaemonSet: %v", err)
+	}
+	container := containers[0].(map[string]interface{})
+	if got := container["image"]; got != "quay.io/myorg/dra-memory-driver:foo" {
+		t.Errorf("Expected image from values, got %q", got)
+	}
+	if got := container["command"]; !reflect.DeepEqual(got, []interface{}{"/bin/custom"}) {
+		t.Errorf("Expected command from values, got %v", got)
+	}
+
+	ref, err := loader.Image(envConfig)
+	if err != nil {
+		t.Fatalf("Image() failed: %v", err)
+	}
+	if ref.String() != "quay.io/myorg/dra-memory-driver:foo" {
+		t.Errorf("Image() = %q, want the image of the values", ref.String())
+	}
+
+	envConfig.Image = "quay.io/myorg/dra-memory-driver:v0.5.0"
+	ref, err = loader.Image(envConfig)
+	if err != nil {
+		t.Fatalf("Image() failed: %v", err)
+	}
+	if ref.String() != envConfig.Image {
+		t.Errorf("Image() = %q, want %q", ref.String(), envConfig.Image)
+	}
+}
+
+func TestRenderImageDigest(t *testing.T) {
+	loader, err := NewChartLoader("")
+	if err != nil {
+		t.Fatalf("Failed to create chart loader: %v", err)
+	}
+
+	const digest = "sha256:2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"
+	tests := []struct {
+		name      string
+		envConfig params.EnvConfig
+		want      string
+	}{
+		{
+			name:      "digest only",
+			envConfig: params.EnvConfig{Image: "quay.io/myorg/driver@" + digest},
+			want:      "quay.io/myorg/driver@" + digest,
+		},
+		{
+			name:      "tag and digest",
+			envConfig: params.EnvConfig{Image: "quay.io/myorg/driver:v1.0.0@" + digest},
+			want:      "quay.io/myorg/driver:v1.0.0@" + digest,
+		},
+		{
+			name: "digest value",
+			envConfig: params.EnvConfig{Values: map[string]any{
+				"image": map[string]any{"repository": "quay.io/myorg/driver", "tag": "v1.0.0", "digest": digest},
+			}},
+			want: "quay.io/myorg/driver:v1.0.0@" + digest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.envConfig.Namespace = "test-namespace"
+			objects, _, err := loader.RenderTemplates(tt.envConfig, []string{"templates/daemonset.yaml"})
+			if err != nil {
+				t.Fatalf("Failed to render chart: %v", err)
+			}
+
+			containers, found, err := getNestedSlice(objects[0].Object, "spec", "template", "spec", "containers")
+			if err != nil || !found || len(containers) == 0 {
+				t.Fatalf("Failed to get containers from DaemonSet: %v", err)
+			}
+			if got := containers[0].(map[string]interface{})["image"]; got != tt.want {
+				t.Errorf("Expected image %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRenderImagePullSecrets(t *testing.T) {
+	loader, err := NewChartLoader("")
+	if err != nil {
+		t.Fatalf("Failed to create chart loader: %v", err)
+	}
+
+	const auth = `{"auths": {"registry.example.com": {"auth": "dXNlcjpzZWNyZXQ="}}}`
+	objects, err := loader.Render(params.EnvConfig{
+		Namespace:        "test-namespace",
+		ImagePullSecrets: []string{"existing"},
+		RegistryAuth:     auth,
+	})
+	if err != nil {
+		t.Fatalf("Failed to render chart: %v", err)
+	}
+
+	want := []interface{}{
+		map[string]interface{}{"name": "existing"},
+		map[string]interface{}{"name": "dra-driver-memory-registry-auth"},
+	}
+	found := map[string]bool{}
+	for _, obj := range objects {
+		var fields []string
+		switch obj.GetKind() {
+		case "ServiceAccount":
+			fields = []string{"imagePullSecrets"}
+		case "DaemonSet":
+			fields = []string{"spec", "template", "spec", "imagePullSecrets"}
+		case "Secret":
+			found[obj.GetKind()] = true
+			if obj.GetName() != "dra-driver-memory-registry-auth" || obj.GetNamespace() != "test-namespace" {
+				t.Errorf("Unexpected Secret %s/%s", obj.GetNamespace(), obj.GetName())
+			}
+			if got, _, _ := unstructured.NestedString(obj.Object, "type"); got != "kubernetes.io/dockerconfigjson" {
+				t.Errorf("Expected a dockerconfigjson Secret, got type %q", got)
+			}
+			if got, _, _ := unstructured.NestedString(obj.Object, "data", ".dockerconfigjson"); got != base64.StdEncoding.EncodeToString([]byte(auth)) {
+				t.Errorf("Unexpected .dockerconfigjson %q", got)
+			}
+			continue
+		default:
+			continue
+		}
+
+		found[obj.GetKind()] = true
+		got, _, err := getNestedSlice(obj.Object, fields...)
+		if err != nil {
+			t.Fatalf("Failed to get imagePullSecrets of %s: %v", obj.GetKind(), err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: expected imagePullSecrets %v, got %v", obj.GetKind(), want, got)
+		}
+	}
+
+	for _, kind := range []string{"ServiceAccount", "DaemonSet", "Secret"} {
+		if !found[kind] {
+			t.Errorf("%s not found in rendered objects", kind)
+		}
+	}
+}
+
+func TestRenderDeviceClasses(t *testing.T) {
+	chartPath := filepath.Join("..", "..", "assets", "deployment", "helm", "dra-driver-memory")
+	loader, err := NewChartLoader(chartPath)
+	if err != nil {
+		t.Fatalf("Failed to create chart loader: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		values        map[string]any
+		expectedName  string
+		expectedExpr  string
+		expectedConfs int
+	}{
+		{
+			name:         "default class selects all devices of the driver",
+			values:       map[string]any{"driver": map[string]any{"name": "dra.test"}},
+			expectedName: "dra.test",
+			expectedExpr: `device.driver == "dra.test"`,
+		},
+		{
+			name: "custom class with selectors and config",
+			values: map[string]any{
+				"deviceClasses": []any{
+					map[string]any{
+						"name":      "large",
+						"selectors": []any{`device.capacity["dra.test"].size >= quantity("1Gi")`},
+						"config": []any{
+							map[string]any{"parameters": map[string]any{"kind": "TestConfig"}},
+						},
+					},
+				},
+			},
+			expectedName:  "large",
+			expectedExpr:  `device.capacity["dra.test"].size >= quantity("1Gi")`,
+			expectedConfs: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objects, err := loader.Render(params.EnvConfig{
+				Namespace: "test-namespace",
+				Values:    tt.values,
+			})
+			if err != nil {
+				t.Fatalf("Failed to render chart: %v", err)
+			}
+
+			var classes []map[string]interface{}
+			for _, obj := range objects {
+				if obj.GetKind() != "DeviceClass" {
+					continue
+				}
+				if obj.GetNamespace() != "" {
+					t.Errorf("DeviceClass %q must be cluster-scoped, got namespace %q", obj.GetName(), obj.GetNamespace())
+				}
+				if obj.GetName() != tt.expectedName {
+					t.Errorf("Expected DeviceClass name %q, got %q", tt.expectedName, obj.GetName())
+				}
+				classes = append(classes, obj.Object)
+			}
+			if len(classes) != 1 {
+				t.Fatalf("Expected 1 DeviceClass, got %d", len(classes))
+			}
+
+			selectors, found, err := getNestedSlice(classes[0], "spec", "selectors")
+			if err != nil || !found || len(selectors) != 1 {
+				t.Fatalf("Expected 1 selector, got %v (err: %v)", selectors, err)
+			}
+			cel := selectors[0].(map[string]interface{})["cel"].(map[string]interface{})
+			if got := cel["expression"]; got != tt.expectedExpr {
+				t.Errorf("Expected selector expression %q, got %q", tt.expectedExpr, got)
+			}
+
+			config, _, err := getNestedSlice(classes[0], "spec", "config")
+			if err != nil {
+				t.Fatalf("Failed to get config: %v", err)
+			}
+			if len(config) != tt.expectedConfs {
+				t.Errorf("Expected %d config entries, got %d", tt.expectedConfs, len(config))
+			}
+		})
+	}
+}
+
+func TestRenderResourceAPIVersions(t *testing.T) {
+	chartPath := filepath.Join("..", "..", "assets", "deployment", "helm", "dra-driver-memory")
+	loader, err := NewChartLoader(chartPath)
+	if err != nil {
+		t.Fatalf("Failed to create chart loader: %v", err)
+	}
+
+	objects, err := loader.Render(params.EnvConfig{
+		Namespace:           "test-namespace",
+		ResourceAPIVersions: []string{"v1beta2", "v1beta1"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to render chart: %v", err)
+	}
+
+	foundPolicy := false
+	for _, obj := range objects {
+		switch obj.GetKind() {
+		case "DeviceClass":
+			if got := obj.GetAPIVersion(); got != "resource.k8s.io/v1beta2" {
+				t.Errorf("Expected DeviceClass to use the preferred version resource.k8s.io/v1beta2, got %q", got)
+			}
+		case "ValidatingAdmissionPolicy":
+			foundPolicy = true
+			rules, found, err := getNestedSlice(obj.Object, "spec", "matchConstraints", "resourceRules")
+			if err != nil || !found || len(rules) == 0 {
+				t.Fatalf("Failed to get resource rules from ValidatingAdmissionPolicy: %v", err)
+			}
+			versions, _, err := getNestedSlice(rules[0].(map[string]interface{}), "apiVersions")
+			if err != nil {
+				t.Fatalf("Failed to get apiVersions: %v", err)
+			}
+			if len(versions) != 2 || versions[0] != "v1beta2" || versions[1] != "v1beta1" {
+				t.Errorf("Expected policy to match apiVersions [v1beta2 v1beta1], got %v", versions)
+			}
+		}
+	}
+
+	if !foundPolicy {
+		t.Error("ValidatingAdmissionPolicy not found in rendered objects")
+	}
+}
+
 func TestParseImage(t *testing.T) {
 	tests := []struct {
 		input          string
@@ -199,3 +588,113 @@ func getNestedSlice(obj map[string]interface{}, fields ...string) ([]interface{}
 
 	return slice, true, nil
 }
+
+func TestRenderHyperShift(t *testing.T) {
+	loader, err := NewChartLoader("")
+	if err != nil {
+		t.Fatalf("Failed to create chart loader: %v", err)
+	}
+
+	objects, err := loader.Render(params.EnvConfig{
+		Namespace: "test-namespace",
+		Platform:  platform.HyperShift,
+	})
+	if err != nil {
+		t.Fatalf("Failed to render chart: %v", err)
+	}
+
+	for _, obj := range objects {
+		if obj.GetKind() == "SecurityContextConstraints" {
+			return
+		}
+	}
+	t.Error("SecurityContextConstraints not found (expected when platform is HyperShift)")
+}
+
+func TestReleaseName(t *testing.T) {
+	loader, err := NewChartLoader("")
+	if err != nil {
+		t.Fatalf("Failed to create chart loader: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		envConfig params.EnvConfig
+		want      string
+		wantErr   bool
+	}{
+		{name: "chart name by default", envConfig: params.EnvConfig{}, want: "dra-driver-memory"},
+		{name: "name override", envConfig: params.EnvConfig{Values: map[string]any{"nameOverride": "dra-driver-cpu"}}, want: "dra-driver-cpu"},
+		{name: "explicit release name", envConfig: params.EnvConfig{ReleaseName: "staging", Values: map[string]any{"nameOverride": "dra-driver-cpu"}}, want: "staging"},
+		{name: "invalid release name", envConfig: params.EnvConfig{ReleaseName: "Not_Valid"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loader.ReleaseName(tt.envConfig)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReleaseName() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ReleaseName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderReleaseName(t *testing.T) {
+	loader, err := NewChartLoader("")
+	if err != nil {
+		t.Fatalf("Failed to create chart loader: %v", err)
+	}
+
+	objects, err := loader.Render(params.EnvConfig{
+		Namespace:   "test-namespace",
+		ReleaseName: "staging",
+	})
+	if err != nil {
+		t.Fatalf("Failed to render chart: %v", err)
+	}
+
+	for _, obj := range objects {
+		if got := obj.GetLabels()["app.kubernetes.io/instance"]; got != "staging" {
+			t.Errorf("%s/%s: expected instance label 'staging', got %q", obj.GetKind(), obj.GetName(), got)
+		}
+		if obj.GetKind() == "ClusterRole" && obj.GetName() != "staging-dra-driver-memory-role" {
+			t.Errorf("Expected ClusterRole 'staging-dra-driver-memory-role', got %q", obj.GetName())
+		}
+	}
+}
+
+func TestRenderReleasesDoNotCollide(t *testing.T) {
+	loader, err := NewChartLoader("")
+	if err != nil {
+		t.Fatalf("Failed to create chart loader: %v", err)
+	}
+
+	owners := map[string]string{}
+	for _, release := range []string{"staging", "canary"} {
+		objects, err := loader.Render(params.EnvConfig{
+			Namespace:   "dra-" + release,
+			ReleaseName: release,
+			Platform:    platform.OpenShift,
+		})
+		if err != nil {
+			t.Fatalf("Failed to render release %s: %v", release, err)
+		}
+
+		for _, obj := range objects {
+			if obj.GetNamespace() != "" {
+				continue
+			}
+			key := obj.GetKind() + "/" + obj.GetName()
+			if owner, ok := owners[key]; ok {
+				t.Errorf("%s is rendered by releases %s and %s", key, owner, release)
+			}
+			owners[key] = release
+			if obj.GetKind() == "DeviceClass" && obj.GetName() != release+".manager.memory.com" {
+				t.Errorf("Expected DeviceClass %q, got %q", release+".manager.memory.com", obj.GetName())
+			}
+		}
+	}
+}
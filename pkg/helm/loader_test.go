@@ -34,6 +34,46 @@ func TestNewChartLoader(t *testing.T) {
 	}
 }
 
+func TestNewChartLoaderEmbedded(t *testing.T) {
+	// Test loading the Helm chart embedded in the binary
+	loader, err := NewChartLoader("")
+	if err != nil {
+		t.Fatalf("Failed to create chart loader: %v", err)
+	}
+
+	chart := loader.GetChart()
+	if chart == nil {
+		t.Fatal("Chart is nil")
+	}
+
+	if chart.Name() != "dra-driver-memory" {
+		t.Errorf("Expected chart name 'dra-driver-memory', got '%s'", chart.Name())
+	}
+
+	if len(chart.Templates) == 0 {
+		t.Fatal("Expected embedded chart to contain templates")
+	}
+
+	foundHelpers := false
+	for _, tmpl := range chart.Templates {
+		if tmpl.Name == "templates/_helpers.tpl" {
+			foundHelpers = true
+		}
+	}
+	if !foundHelpers {
+		t.Error("Expected embedded chart to contain templates/_helpers.tpl")
+	}
+
+	objects, err := loader.Render(params.EnvConfig{Namespace: "test-namespace"})
+	if err != nil {
+		t.Fatalf("Failed to render embedded chart: %v", err)
+	}
+
+	if len(objects) == 0 {
+		t.Error("Expected at least one object to be rendered")
+	}
+}
+
 func TestRenderChart(t *testing.T) {
 	// Test rendering the Helm chart
 	chartPath := filepath.Join("..", "..", "assets", "deployment", "helm", "dra-driver-memory")
@@ -106,3 +146,29 @@ func TestRenderChartWithCustomValues(t *testing.T) {
 
 	t.Logf("Rendered %d Kubernetes objects with custom values", len(objects))
 }
+
+func TestRenderTemplates(t *testing.T) {
+	loader, err := NewChartLoader("")
+	if err != nil {
+		t.Fatalf("Failed to create chart loader: %v", err)
+	}
+
+	envConfig := params.EnvConfig{Namespace: "test-namespace"}
+
+	objects, _, err := loader.RenderTemplates(envConfig, []string{"templates/daemonset.yaml", "./templates/serviceaccount.yaml"})
+	if err != nil {
+		t.Fatalf("Failed to render templates: %v", err)
+	}
+	if len(objects) != 2 || objects[0].GetKind() != "ServiceAccount" || objects[1].GetKind() != "DaemonSet" {
+		t.Errorf("Expected the ServiceAccount and the DaemonSet, got %v", objectNames(objects))
+	}
+
+	if _, _, err := loader.RenderTemplates(envConfig, []string{"templates/missing.yaml"}); err == nil {
+		t.Error("Expected an error for a template missing from the chart")
+	}
+
+	// The SCC template renders nothing unless openshift.enabled is set
+	if _, _, err := loader.RenderTemplates(envConfig, []string{"templates/securitycontextconstraints.yaml"}); err == nil {
+		t.Error("Expected an error for a template rendering no objects")
+	}
+}
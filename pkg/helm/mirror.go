@@ -0,0 +1,101 @@
+package helm
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/klog/v2"
+
+	"github.com/Tal-or/dra-deployer/pkg/image"
+)
+
+// podSpecPaths are the paths of the pod specs of the workload kinds
+var podSpecPaths = map[string][]string{
+	"Pod":         {"spec"},
+	"DaemonSet":   {"spec", "template", "spec"},
+	"Deployment":  {"spec", "template", "spec"},
+	"StatefulSet": {"spec", "template", "spec"},
+	"ReplicaSet":  {"spec", "template", "spec"},
+	"Job":         {"spec", "template", "spec"},
+	"CronJob":     {"spec", "jobTemplate", "spec", "template", "spec"},
+}
+
+// ImageRewrite records a container image pointed at its registry mirror
+type ImageRewrite struct {
+	Object    string // Object is the rendered workload as Kind/name
+	Container string
+	From      string
+	To        string
+}
+
+// mirrorImage parses img and points it at its registry mirror. Short names such as busybox:1.36
+// are expanded like container runtimes do, so they match docker.io mirrors.
+// The returned bool is false if no mirror matches img.
+func mirrorImage(img string, mirrors image.Mirrors) (image.Reference, bool, error) {
+	ref, err := image.ParseNormalized(img)
+	if err != nil {
+		return image.Reference{}, false, fmt.Errorf("failed to parse image: %w", err)
+	}
+	if mirrors.Empty() {
+		return ref, false, nil
+	}
+	return mirrors.Rewrite(ref)
+}
+
+// rewriteContainerImages points the images of every container of the rendered workloads at their
+// registry mirror, covering the plugin image and the extra containers of a custom chart alike.
+// It returns the rewritten images.
+func rewriteContainerImages(objects []*unstructured.Unstructured, mirrors image.Mirrors) ([]ImageRewrite, error) {
+	if mirrors.Empty() {
+		return nil, nil
+	}
+
+	var rewrites []ImageRewrite
+	for _, obj := range objects {
+		path, ok := podSpecPaths[obj.GetKind()]
+		if !ok {
+			continue
+		}
+
+		for _, field := range []string{"initContainers", "containers", "ephemeralContainers"} {
+			containers, found, err := unstructured.NestedSlice(obj.Object, append(path, field)...)
+			if err != nil || !found {
+				continue
+			}
+
+			changed := false
+			for _, c := range containers {
+				container, ok := c.(map[string]any)
+				if !ok {
+					continue
+				}
+				img, _ := container["image"].(string)
+				if img == "" {
+					continue
+				}
+
+				ref, rewritten, err := mirrorImage(img, mirrors)
+				if err != nil {
+					return nil, fmt.Errorf("%s/%s container %v: %w", obj.GetKind(), obj.GetName(), container["name"], err)
+				}
+				if !rewritten {
+					continue
+				}
+				container["image"] = ref.String()
+				changed = true
+
+				name, _ := container["name"].(string)
+				rewrite := ImageRewrite{Object: obj.GetKind() + "/" + obj.GetName(), Container: name, From: img, To: ref.String()}
+				klog.V(4).InfoS("Rewrote image for registry mirror", "object", rewrite.Object, "container", rewrite.Container, "from", rewrite.From, "to", rewrite.To)
+				rewrites = append(rewrites, rewrite)
+			}
+
+			if changed {
+				if err := unstructured.SetNestedSlice(obj.Object, containers, append(path, field)...); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return rewrites, nil
+}
@@ -0,0 +1,154 @@
+package helm
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/Tal-or/dra-deployer/pkg/image"
+	"github.com/Tal-or/dra-deployer/pkg/params"
+)
+
+var testMirrors = image.Mirrors{Mirrors: []image.Mirror{{Source: "quay.io", Destination: "mirror.example.com/quay"}}}
+
+func TestRenderRegistryMirrors(t *testing.T) {
+	loader, err := NewChartLoader("")
+	if err != nil {
+		t.Fatalf("Failed to create chart loader: %v", err)
+	}
+
+	objects, _, err := loader.RenderTemplates(params.EnvConfig{
+		Namespace:       "test-namespace",
+		Image:           "quay.io/myorg/driver:v1.0.0",
+		RegistryMirrors: testMirrors,
+	}, []string{"templates/daemonset.yaml"})
+	if err != nil {
+		t.Fatalf("Failed to render chart: %v", err)
+	}
+
+	containers, found, err := getNestedSlice(objects[0].Object, "spec", "template", "spec", "containers")
+	if err != nil || !found || len(containers) == 0 {
+		t.Fatalf("Failed to get containers from DaemonSet: %v", err)
+	}
+	if got := containers[0].(map[string]interface{})["image"]; got != "mirror.example.com/quay/myorg/driver:v1.0.0" {
+		t.Errorf("Expected the mirrored image, got %q", got)
+	}
+}
+
+func TestRenderRegistryMirrorsRewriteOnce(t *testing.T) {
+	loader, err := NewChartLoader("")
+	if err != nil {
+		t.Fatalf("Failed to create chart loader: %v", err)
+	}
+	// The mirror lives under its source registry, a second rewrite would nest it again
+	mirrors := image.Mirrors{Mirrors: []image.Mirror{{Source: "quay.io", Destination: "quay.io/mirror"}}}
+
+	tests := []struct {
+		name  string
+		image string
+		want  string
+	}{
+		{
+			name:  "image flag",
+			image: "quay.io/myorg/driver:v1.0.0",
+			want:  "quay.io/mirror/myorg/driver:v1.0.0",
+		},
+		{
+			name: "chart default image",
+			want: "quay.io/mirror/titzhak/dra-example-driver:v0.1.0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			envConfig := params.EnvConfig{
+				Namespace:       "test-namespace",
+				Image:           tt.image,
+				RegistryMirrors: mirrors,
+			}
+			objects, _, err := loader.RenderTemplates(envConfig, []string{"templates/daemonset.yaml"})
+			if err != nil {
+				t.Fatalf("Failed to render chart: %v", err)
+			}
+
+			containers, found, err := getNestedSlice(objects[0].Object, "spec", "template", "spec", "containers")
+			if err != nil || !found || len(containers) == 0 {
+				t.Fatalf("Failed to get containers from DaemonSet: %v", err)
+			}
+			if got := containers[0].(map[string]interface{})["image"]; got != tt.want {
+				t.Errorf("Expected the image mirrored once %q, got %q", tt.want, got)
+			}
+
+			// The values keep the source image, the digest is resolved and verified for it
+			ref, err := loader.Image(envConfig)
+			if err != nil {
+				t.Fatalf("Image() failed: %v", err)
+			}
+			if strings.HasPrefix(ref.Image, "quay.io/mirror") {
+				t.Errorf("Expected the source image from Image(), got %q", ref.String())
+			}
+		})
+	}
+}
+
+func TestRewriteContainerImages(t *testing.T) {
+	ds := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "DaemonSet",
+		"metadata":   map[string]interface{}{"name": "plugin"},
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"initContainers": []interface{}{
+						map[string]interface{}{"name": "init", "image": "quay.io/org/init:v1"},
+					},
+					"containers": []interface{}{
+						map[string]interface{}{"name": "plugin", "image": "mirror.example.com/quay/org/plugin:v1"},
+						map[string]interface{}{"name": "sidecar", "image": "registry.k8s.io/sidecar:v2"},
+						map[string]interface{}{"name": "shell", "image": "busybox:1.36"},
+					},
+				},
+			},
+		},
+	}}
+	sa := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ServiceAccount",
+		"metadata":   map[string]interface{}{"name": "plugin"},
+	}}
+
+	// Short names are Docker Hub images
+	mirrors := image.Mirrors{Mirrors: append([]image.Mirror{{Source: "docker.io", Destination: "mirror.example.com/docker"}}, testMirrors.Mirrors...)}
+
+	rewrites, err := rewriteContainerImages([]*unstructured.Unstructured{sa, ds}, mirrors)
+	if err != nil {
+		t.Fatalf("rewriteContainerImages() failed: %v", err)
+	}
+
+	want := map[string]string{
+		"initContainers/init": "mirror.example.com/quay/org/init:v1",
+		"containers/plugin":   "mirror.example.com/quay/org/plugin:v1",
+		"containers/sidecar":  "registry.k8s.io/sidecar:v2",
+		"containers/shell":    "mirror.example.com/docker/library/busybox:1.36",
+	}
+	for _, field := range []string{"initContainers", "containers"} {
+		containers, _, _ := unstructured.NestedSlice(ds.Object, "spec", "template", "spec", field)
+		for _, c := range containers {
+			container := c.(map[string]interface{})
+			key := field + "/" + container["name"].(string)
+			if container["image"] != want[key] {
+				t.Errorf("%s: expected image %q, got %q", key, want[key], container["image"])
+			}
+		}
+	}
+
+	expected := []ImageRewrite{
+		{Object: "DaemonSet/plugin", Container: "init", From: "quay.io/org/init:v1", To: "mirror.example.com/quay/org/init:v1"},
+		{Object: "DaemonSet/plugin", Container: "shell", From: "busybox:1.36", To: "mirror.example.com/docker/library/busybox:1.36"},
+	}
+	if !reflect.DeepEqual(rewrites, expected) {
+		t.Errorf("Expected rewrites %+v, got %+v", expected, rewrites)
+	}
+}
@@ -0,0 +1,67 @@
+package helm
+
+import (
+	"sort"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// installOrder lists the kinds in the order they are installed: objects are created
+// after the objects they depend on, e.g. the plugin DaemonSet after its ServiceAccount,
+// RBAC and SCC. Kinds not listed are installed last.
+var installOrder = []string{
+	"Namespace",
+	"SecurityContextConstraints",
+	"ServiceAccount",
+	"Secret",
+	"ConfigMap",
+	"ClusterRole",
+	"ClusterRoleBinding",
+	"Role",
+	"RoleBinding",
+	"ValidatingAdmissionPolicy",
+	"ValidatingAdmissionPolicyBinding",
+	"DeviceClass",
+	"DaemonSet",
+	"Deployment",
+}
+
+var installOrderIndex = func() map[string]int {
+	index := make(map[string]int, len(installOrder))
+	for i, kind := range installOrder {
+		index[kind] = i
+	}
+	return index
+}()
+
+// SortByInstallOrder sorts objects by the install order of their kind; objects of the
+// same kind are sorted by name and namespace, kinds without install order by kind name
+func SortByInstallOrder(objects []*unstructured.Unstructured) {
+	sort.SliceStable(objects, func(i, j int) bool {
+		return installsBefore(objects[i], objects[j])
+	})
+}
+
+// SortByDeleteOrder sorts objects in the reverse install order
+func SortByDeleteOrder(objects []*unstructured.Unstructured) {
+	sort.SliceStable(objects, func(i, j int) bool {
+		return installsBefore(objects[j], objects[i])
+	})
+}
+
+func installsBefore(a, b *unstructured.Unstructured) bool {
+	ai, aKnown := installOrderIndex[a.GetKind()]
+	bi, bKnown := installOrderIndex[b.GetKind()]
+	switch {
+	case aKnown && bKnown && ai != bi:
+		return ai < bi
+	case aKnown != bKnown:
+		return aKnown
+	case a.GetKind() != b.GetKind():
+		return a.GetKind() < b.GetKind()
+	case a.GetName() != b.GetName():
+		return a.GetName() < b.GetName()
+	default:
+		return a.GetNamespace() < b.GetNamespace()
+	}
+}
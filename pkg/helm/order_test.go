@@ -0,0 +1,93 @@
+package helm
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/Tal-or/dra-deployer/pkg/params"
+)
+
+func newOrderObject(kind, name string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetKind(kind)
+	obj.SetName(name)
+	return obj
+}
+
+func objectNames(objects []*unstructured.Unstructured) []string {
+	names := make([]string, 0, len(objects))
+	for _, obj := range objects {
+		names = append(names, obj.GetKind()+"/"+obj.GetName())
+	}
+	return names
+}
+
+func TestSortByInstallOrder(t *testing.T) {
+	objects := []*unstructured.Unstructured{
+		newOrderObject("DaemonSet", "plugin"),
+		newOrderObject("Widget", "b"),
+		newOrderObject("DeviceClass", "b.example.com"),
+		newOrderObject("ClusterRoleBinding", "plugin"),
+		newOrderObject("Gadget", "a"),
+		newOrderObject("DeviceClass", "a.example.com"),
+		newOrderObject("ServiceAccount", "plugin"),
+		newOrderObject("ClusterRole", "plugin"),
+		newOrderObject("ValidatingAdmissionPolicy", "policy"),
+		newOrderObject("SecurityContextConstraints", "plugin"),
+	}
+
+	expected := []string{
+		"SecurityContextConstraints/plugin",
+		"ServiceAccount/plugin",
+		"ClusterRole/plugin",
+		"ClusterRoleBinding/plugin",
+		"ValidatingAdmissionPolicy/policy",
+		"DeviceClass/a.example.com",
+		"DeviceClass/b.example.com",
+		"DaemonSet/plugin",
+		"Gadget/a",
+		"Widget/b",
+	}
+
+	SortByInstallOrder(objects)
+	if got := objectNames(objects); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Unexpected install order:\ngot:  %v\nwant: %v", got, expected)
+	}
+
+	SortByDeleteOrder(objects)
+	for i, j := 0, len(expected)-1; i < j; i, j = i+1, j-1 {
+		expected[i], expected[j] = expected[j], expected[i]
+	}
+	if got := objectNames(objects); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Unexpected delete order:\ngot:  %v\nwant: %v", got, expected)
+	}
+}
+
+func TestRenderIsDeterministic(t *testing.T) {
+	loader, err := NewChartLoader("")
+	if err != nil {
+		t.Fatalf("Failed to create chart loader: %v", err)
+	}
+
+	var first []string
+	for i := 0; i < 10; i++ {
+		objects, err := loader.Render(params.EnvConfig{Namespace: "test-namespace"})
+		if err != nil {
+			t.Fatalf("Failed to render chart: %v", err)
+		}
+		names := objectNames(objects)
+		if first == nil {
+			first = names
+			continue
+		}
+		if !reflect.DeepEqual(names, first) {
+			t.Fatalf("Render order changed between runs:\nfirst: %v\nnow:   %v", first, names)
+		}
+	}
+
+	if first[len(first)-1] != "DaemonSet/dra-driver-memory-kubeletplugin" {
+		t.Errorf("Expected the DaemonSet to be rendered last, got %v", first)
+	}
+}
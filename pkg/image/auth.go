@@ -0,0 +1,62 @@
+package image
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// authFile is the part of a containers-auth.json(5) or docker config.json file a kubelet understands
+type authFile struct {
+	Auths map[string]json.RawMessage `json:"auths"`
+}
+
+// ReadAuthFile returns the credentials for repository (e.g. quay.io/myorg/dra-driver) stored in the
+// registry auth file at path, as a file suitable for a kubernetes.io/dockerconfigjson Secret. Only the
+// "auths" entries of the registry of repository, or of a namespace holding it, are kept: the credentials
+// of other registries and the credential helper settings are dropped. The file must store the credentials
+// inline: credential helpers are not available to the kubelet.
+func ReadAuthFile(path, repository string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read registry auth file: %w", err)
+	}
+
+	var auth authFile
+	if err := json.Unmarshal(data, &auth); err != nil {
+		return "", fmt.Errorf("failed to parse registry auth file %s: %w", path, err)
+	}
+	if len(auth.Auths) == 0 {
+		return "", fmt.Errorf("registry auth file %s has no credentials under \"auths\"", path)
+	}
+
+	filtered := authFile{Auths: map[string]json.RawMessage{}}
+	for key, entry := range auth.Auths {
+		if authKeyMatches(key, repository) {
+			filtered.Auths[key] = entry
+		}
+	}
+	if len(filtered.Auths) == 0 {
+		return "", fmt.Errorf("registry auth file %s has no credentials for %s under \"auths\"", path, repository)
+	}
+
+	data, err = json.Marshal(filtered)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode registry auth file: %w", err)
+	}
+	return string(data), nil
+}
+
+// authKeyMatches returns true if the "auths" key, a registry or a repository namespace, holds the
+// credentials of repository. Docker config.json files may key registries by URL, e.g. https://index.docker.io/v1/.
+func authKeyMatches(key, repository string) bool {
+	if scheme := strings.Index(key, "://"); scheme >= 0 {
+		key, _, _ = strings.Cut(key[scheme+3:], "/")
+	}
+	key = strings.TrimSuffix(key, "/")
+	if key == "index.docker.io" || key == "registry-1.docker.io" {
+		key = "docker.io"
+	}
+	return repository == key || strings.HasPrefix(repository, key+"/")
+}
@@ -0,0 +1,90 @@
+package image
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadAuthFile(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    string
+		repository string
+		want       string
+		wantErr    bool
+	}{
+		{
+			name:       "inline credentials",
+			content:    `{"auths": {"quay.io": {"auth": "dXNlcjpzZWNyZXQ="}}}`,
+			repository: "quay.io/myorg/dra-driver",
+			want:       `{"auths":{"quay.io":{"auth":"dXNlcjpzZWNyZXQ="}}}`,
+		},
+		{
+			name: "other registries and credential helpers are dropped",
+			content: `{
+				"auths": {
+					"quay.io": {"auth": "dXNlcjpzZWNyZXQ="},
+					"quay.io/myorg": {"auth": "b3JnOnNlY3JldA=="},
+					"quay.io/otherorg": {"auth": "b3RoZXI6c2VjcmV0"},
+					"registry.example.com": {"auth": "b3RoZXI6c2VjcmV0"}
+				},
+				"credsStore": "desktop",
+				"credHelpers": {"quay.io": "secretservice"}
+			}`,
+			repository: "quay.io/myorg/dra-driver",
+			want:       `{"auths":{"quay.io":{"auth":"dXNlcjpzZWNyZXQ="},"quay.io/myorg":{"auth":"b3JnOnNlY3JldA=="}}}`,
+		},
+		{
+			name:       "registry keyed by URL",
+			content:    `{"auths": {"https://index.docker.io/v1/": {"auth": "dXNlcjpzZWNyZXQ="}}}`,
+			repository: "docker.io/myorg/dra-driver",
+			want:       `{"auths":{"https://index.docker.io/v1/":{"auth":"dXNlcjpzZWNyZXQ="}}}`,
+		},
+		{
+			name:       "no credentials for the registry",
+			content:    `{"auths": {"registry.example.com": {"auth": "dXNlcjpzZWNyZXQ="}}}`,
+			repository: "quay.io/myorg/dra-driver",
+			wantErr:    true,
+		},
+		{
+			name:       "registry name prefix",
+			content:    `{"auths": {"quay.io": {"auth": "dXNlcjpzZWNyZXQ="}}}`,
+			repository: "quay.iot/myorg/dra-driver",
+			wantErr:    true,
+		},
+		{
+			name:       "credential helpers only",
+			content:    `{"credHelpers": {"quay.io": "secretservice"}}`,
+			repository: "quay.io/myorg/dra-driver",
+			wantErr:    true,
+		},
+		{
+			name:       "not json",
+			content:    `auths: {}`,
+			repository: "quay.io/myorg/dra-driver",
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "auth.json")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatalf("Failed to write auth file: %v", err)
+			}
+
+			got, err := ReadAuthFile(path, tt.repository)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReadAuthFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("ReadAuthFile() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := ReadAuthFile(filepath.Join(t.TempDir(), "missing.json"), "quay.io/myorg/dra-driver"); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
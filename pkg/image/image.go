@@ -1,37 +1,63 @@
 package image
 
 import (
-	"fmt"
-
 	"github.com/containers/image/v5/docker/reference"
 	"k8s.io/klog/v2"
 )
 
+// Reference is a container image reference split into repository, tag and digest.
+// Tag and Digest may both be set, in which case the digest pins the image and the tag is informative.
 type Reference struct {
-	Image string
-	Tag   string
+	Image  string
+	Tag    string
+	Digest string
 }
 
+// Parse parses image into its repository, tag and digest.
+// A reference with neither a tag nor a digest uses the latest tag.
 func Parse(image string) (Reference, error) {
 	named, err := reference.ParseNamed(image)
 	if err != nil {
 		return Reference{}, err
 	}
+	return fromNamed(named, image), nil
+}
 
-	tag := "latest"
-	tagged, ok := named.(reference.Tagged)
-	if ok {
-		tag = tagged.Tag()
-	} else {
-		klog.V(5).InfoS("Image is not tagged, using latest as tag", "image", image)
+// ParseNormalized parses image like Parse, but also accepts the short names container runtimes
+// expand, e.g. busybox:1.36 for docker.io/library/busybox:1.36
+func ParseNormalized(image string) (Reference, error) {
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return Reference{}, err
 	}
+	return fromNamed(named, image), nil
+}
 
-	return Reference{
+func fromNamed(named reference.Named, image string) Reference {
+	ref := Reference{
 		Image: named.Name(),
-		Tag:   tag,
-	}, nil
+	}
+	if tagged, ok := named.(reference.Tagged); ok {
+		ref.Tag = tagged.Tag()
+	}
+	if digested, ok := named.(reference.Digested); ok {
+		ref.Digest = digested.Digest().String()
+	}
+	if ref.Tag == "" && ref.Digest == "" {
+		klog.V(5).InfoS("Image is not tagged, using latest as tag", "image", image)
+		ref.Tag = "latest"
+	}
+	return ref
 }
 
+// String returns the reference as repo:tag, repo@digest or repo:tag@digest
 func (r Reference) String() string {
-	return fmt.Sprintf("%s:%s", r.Image, r.Tag)
+	s := r.Image
+	if r.Tag != "" {
+		s += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		s += "@" + r.Digest
+	}
+	return s
 }
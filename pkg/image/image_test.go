@@ -6,11 +6,12 @@ import (
 
 func TestParse(t *testing.T) {
 	tests := []struct {
-		name      string
-		input     string
-		wantImage string
-		wantTag   string
-		wantErr   bool
+		name       string
+		input      string
+		wantImage  string
+		wantTag    string
+		wantDigest string
+		wantErr    bool
 	}{
 		{
 			name:      "full image with tag",
@@ -54,6 +55,24 @@ func TestParse(t *testing.T) {
 			wantTag:   "latest",
 			wantErr:   false,
 		},
+		{
+			name:       "image with digest",
+			input:      "quay.io/organization/image@sha256:2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae",
+			wantImage:  "quay.io/organization/image",
+			wantDigest: "sha256:2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae",
+		},
+		{
+			name:       "image with tag and digest",
+			input:      "quay.io/organization/image:v1.0.0@sha256:2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae",
+			wantImage:  "quay.io/organization/image",
+			wantTag:    "v1.0.0",
+			wantDigest: "sha256:2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae",
+		},
+		{
+			name:    "invalid digest",
+			input:   "quay.io/organization/image@sha256:abc",
+			wantErr: true,
+		},
 		{
 			name:    "empty string",
 			input:   "",
@@ -98,10 +117,40 @@ func TestParse(t *testing.T) {
 			if got.Tag != tt.wantTag {
 				t.Errorf("Parse(%q).Tag = %q, want %q", tt.input, got.Tag, tt.wantTag)
 			}
+
+			if got.Digest != tt.wantDigest {
+				t.Errorf("Parse(%q).Digest = %q, want %q", tt.input, got.Digest, tt.wantDigest)
+			}
 		})
 	}
 }
 
+func TestParseNormalized(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{input: "busybox:1.36", want: "docker.io/library/busybox:1.36"},
+		{input: "myorg/driver", want: "docker.io/myorg/driver:latest"},
+		{input: "quay.io/myorg/driver:v1", want: "quay.io/myorg/driver:v1"},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseNormalized(tt.input)
+		if err != nil {
+			t.Errorf("ParseNormalized(%q) failed: %v", tt.input, err)
+			continue
+		}
+		if got.String() != tt.want {
+			t.Errorf("ParseNormalized(%q) = %q, want %q", tt.input, got.String(), tt.want)
+		}
+	}
+
+	if _, err := ParseNormalized("quay.io/MyOrg/driver"); err == nil {
+		t.Error("Expected an error for an invalid image")
+	}
+}
+
 func TestReferenceString(t *testing.T) {
 	tests := []struct {
 		name string
@@ -132,6 +181,23 @@ func TestReferenceString(t *testing.T) {
 			},
 			want: "localhost:5000/myapp:dev",
 		},
+		{
+			name: "image with digest",
+			ref: Reference{
+				Image:  "quay.io/org/image",
+				Digest: "sha256:2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae",
+			},
+			want: "quay.io/org/image@sha256:2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae",
+		},
+		{
+			name: "image with tag and digest",
+			ref: Reference{
+				Image:  "quay.io/org/image",
+				Tag:    "v1.0.0",
+				Digest: "sha256:2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae",
+			},
+			want: "quay.io/org/image:v1.0.0@sha256:2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae",
+		},
 	}
 
 	for _, tt := range tests {
@@ -162,6 +228,18 @@ func TestParseRoundTrip(t *testing.T) {
 			input: "localhost:5000/app:dev",
 			want:  "localhost:5000/app:dev",
 		},
+		{
+			input: "quay.io/org/image",
+			want:  "quay.io/org/image:latest",
+		},
+		{
+			input: "quay.io/org/image@sha256:2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae",
+			want:  "quay.io/org/image@sha256:2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae",
+		},
+		{
+			input: "quay.io/org/image:v1.0.0@sha256:2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae",
+			want:  "quay.io/org/image:v1.0.0@sha256:2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae",
+		},
 	}
 
 	for _, tt := range tests {
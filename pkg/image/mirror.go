@@ -0,0 +1,108 @@
+package image
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/containers/image/v5/docker/reference"
+	"github.com/containers/image/v5/pkg/sysregistriesv2"
+	"github.com/containers/image/v5/types"
+)
+
+// Mirror rewrites the images under Source to Destination, e.g. quay.io/org=mirror.example.com/quay/org
+type Mirror struct {
+	Source      string
+	Destination string
+}
+
+// ParseMirror parses a src=dst mirror
+func ParseMirror(value string) (Mirror, error) {
+	src, dst, ok := strings.Cut(value, "=")
+	src = strings.TrimSuffix(src, "/")
+	dst = strings.TrimSuffix(dst, "/")
+	if !ok || src == "" || dst == "" {
+		return Mirror{}, fmt.Errorf("invalid registry mirror %q, must be src=dst", value)
+	}
+	return Mirror{Source: src, Destination: dst}, nil
+}
+
+// String returns the mirror as src=dst
+func (m Mirror) String() string {
+	return m.Source + "=" + m.Destination
+}
+
+// matches returns true if image is Source or a repository under it
+func (m Mirror) matches(image string) bool {
+	return image == m.Source || strings.HasPrefix(image, m.Source+"/")
+}
+
+// Mirrors rewrites image references for disconnected clusters. The explicit mirrors are tried first,
+// the most specific Source winning; references none of them matches are looked up in RegistriesConf.
+type Mirrors struct {
+	Mirrors []Mirror
+	// RegistriesConf is the path of a containers-registries.conf(5) file, the first mirror
+	// of the registry matching a reference is used, honoring pull-from-mirror
+	RegistriesConf string
+}
+
+// Empty returns true if no mirror is configured
+func (m Mirrors) Empty() bool {
+	return len(m.Mirrors) == 0 && m.RegistriesConf == ""
+}
+
+// Rewrite returns ref pointed at its mirror. The returned bool is false if no mirror matches ref,
+// in which case ref is returned unchanged.
+func (m Mirrors) Rewrite(ref Reference) (Reference, bool, error) {
+	var match *Mirror
+	for i := range m.Mirrors {
+		if m.Mirrors[i].matches(ref.Image) && (match == nil || len(m.Mirrors[i].Source) > len(match.Source)) {
+			match = &m.Mirrors[i]
+		}
+	}
+	if match != nil {
+		rewritten := ref
+		rewritten.Image = match.Destination + strings.TrimPrefix(ref.Image, match.Source)
+		if _, err := reference.ParseNamed(rewritten.String()); err != nil {
+			return ref, false, fmt.Errorf("invalid image %s for registry mirror %s: %w", rewritten.String(), match.String(), err)
+		}
+		return rewritten, true, nil
+	}
+
+	if m.RegistriesConf == "" {
+		return ref, false, nil
+	}
+	return m.rewriteFromRegistriesConf(ref)
+}
+
+func (m Mirrors) rewriteFromRegistriesConf(ref Reference) (Reference, bool, error) {
+	sys := &types.SystemContext{
+		SystemRegistriesConfPath: m.RegistriesConf,
+		// Only read the drop-in files next to the given file, never the host configuration
+		SystemRegistriesConfDirPath: m.RegistriesConf + ".d",
+	}
+
+	named, err := reference.ParseNamed(ref.String())
+	if err != nil {
+		return ref, false, err
+	}
+	registry, err := sysregistriesv2.FindRegistry(sys, named.String())
+	if err != nil {
+		return ref, false, fmt.Errorf("failed to read registries configuration %s: %w", m.RegistriesConf, err)
+	}
+	if registry == nil {
+		return ref, false, nil
+	}
+
+	sources, err := registry.PullSourcesFromReference(named)
+	if err != nil {
+		return ref, false, err
+	}
+	// The registry itself is always the last source, any source before it is a mirror
+	if len(sources) < 2 {
+		return ref, false, nil
+	}
+
+	rewritten := ref
+	rewritten.Image = sources[0].Reference.Name()
+	return rewritten, true, nil
+}
@@ -0,0 +1,161 @@
+package image
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseMirror(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Mirror
+		wantErr bool
+	}{
+		{input: "quay.io=mirror.example.com/quay", want: Mirror{Source: "quay.io", Destination: "mirror.example.com/quay"}},
+		{input: "quay.io/org/=mirror.example.com/org/", want: Mirror{Source: "quay.io/org", Destination: "mirror.example.com/org"}},
+		{input: "quay.io", wantErr: true},
+		{input: "=mirror.example.com", wantErr: true},
+		{input: "quay.io=", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseMirror(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseMirror(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseMirror(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMirrorsRewrite(t *testing.T) {
+	mirrors := Mirrors{Mirrors: []Mirror{
+		{Source: "quay.io", Destination: "mirror.example.com/quay"},
+		{Source: "quay.io/fromani", Destination: "mirror.example.com/drivers"},
+	}}
+
+	tests := []struct {
+		name          string
+		ref           Reference
+		want          string
+		wantRewritten bool
+	}{
+		{
+			name:          "registry",
+			ref:           Reference{Image: "quay.io/titzhak/dra-cpu-driver", Tag: "latest"},
+			want:          "mirror.example.com/quay/titzhak/dra-cpu-driver:latest",
+			wantRewritten: true,
+		},
+		{
+			name:          "most specific source wins",
+			ref:           Reference{Image: "quay.io/fromani/dra-driver-memory", Tag: "v1", Digest: testDigest},
+			want:          "mirror.example.com/drivers/dra-driver-memory:v1@" + testDigest,
+			wantRewritten: true,
+		},
+		{
+			name: "source matches path components only",
+			ref:  Reference{Image: "quay.iox/org/image", Tag: "v1"},
+			want: "quay.iox/org/image:v1",
+		},
+		{
+			name: "no mirror",
+			ref:  Reference{Image: "registry.k8s.io/pause", Tag: "3.10"},
+			want: "registry.k8s.io/pause:3.10",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, rewritten, err := mirrors.Rewrite(tt.ref)
+			if err != nil {
+				t.Fatalf("Rewrite() failed: %v", err)
+			}
+			if rewritten != tt.wantRewritten {
+				t.Errorf("Rewrite() rewritten = %v, want %v", rewritten, tt.wantRewritten)
+			}
+			if got.String() != tt.want {
+				t.Errorf("Rewrite() = %q, want %q", got.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestMirrorsRewriteRegistriesConf(t *testing.T) {
+	conf := filepath.Join(t.TempDir(), "registries.conf")
+	err := os.WriteFile(conf, []byte(`
+[[registry]]
+prefix = "quay.io/fromani"
+location = "quay.io/fromani"
+[[registry.mirror]]
+location = "mirror.example.com/fromani"
+
+[[registry]]
+location = "registry.k8s.io"
+[[registry.mirror]]
+location = "mirror.example.com/k8s"
+pull-from-mirror = "digest-only"
+`), 0o600)
+	if err != nil {
+		t.Fatalf("Failed to write registries.conf: %v", err)
+	}
+
+	mirrors := Mirrors{
+		Mirrors:        []Mirror{{Source: "quay.io/fromani/dra-driver-memory", Destination: "flag.example.com/dra-driver-memory"}},
+		RegistriesConf: conf,
+	}
+
+	tests := []struct {
+		name          string
+		ref           Reference
+		want          string
+		wantRewritten bool
+	}{
+		{
+			name:          "explicit mirror wins",
+			ref:           Reference{Image: "quay.io/fromani/dra-driver-memory", Tag: "v1"},
+			want:          "flag.example.com/dra-driver-memory:v1",
+			wantRewritten: true,
+		},
+		{
+			name:          "registries.conf mirror",
+			ref:           Reference{Image: "quay.io/fromani/other", Tag: "v1"},
+			want:          "mirror.example.com/fromani/other:v1",
+			wantRewritten: true,
+		},
+		{
+			name: "digest-only mirror skips tags",
+			ref:  Reference{Image: "registry.k8s.io/pause", Tag: "3.10"},
+			want: "registry.k8s.io/pause:3.10",
+		},
+		{
+			name:          "digest-only mirror",
+			ref:           Reference{Image: "registry.k8s.io/pause", Digest: testDigest},
+			want:          "mirror.example.com/k8s/pause@" + testDigest,
+			wantRewritten: true,
+		},
+		{
+			name: "no registry",
+			ref:  Reference{Image: "docker.io/library/busybox", Tag: "latest"},
+			want: "docker.io/library/busybox:latest",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, rewritten, err := mirrors.Rewrite(tt.ref)
+			if err != nil {
+				t.Fatalf("Rewrite() failed: %v", err)
+			}
+			if rewritten != tt.wantRewritten {
+				t.Errorf("Rewrite() rewritten = %v, want %v", rewritten, tt.wantRewritten)
+			}
+			if got.String() != tt.want {
+				t.Errorf("Rewrite() = %q, want %q", got.String(), tt.want)
+			}
+		})
+	}
+}
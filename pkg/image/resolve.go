@@ -0,0 +1,71 @@
+package image
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/containers/image/v5/docker"
+	"github.com/containers/image/v5/docker/reference"
+	"github.com/containers/image/v5/types"
+	"k8s.io/klog/v2"
+)
+
+// ResolveOptions configures how the registry is contacted to resolve a digest or verify a signature
+type ResolveOptions struct {
+	// AuthFile is the path of the registry credentials file, the containers-auth.json(5)
+	// default locations (including ~/.docker/config.json) are used when empty
+	AuthFile string
+	// TLSVerify requires HTTPS and verifies the registry certificates; when false, plain HTTP
+	// and self-signed certificates are accepted
+	TLSVerify bool
+	// Mirrors are the registry mirrors the image is pulled from: the digest is resolved, and the
+	// signatures are fetched, from the mirror of the reference, which keeps naming the source image
+	Mirrors Mirrors
+
+	// registriesDir is the registries.d directory configuring where signatures are looked up,
+	// the containers-registries.d(5) default locations are used when empty
+	registriesDir string
+}
+
+// systemContext returns the containers/image configuration contacting the registry with opts
+func (opts ResolveOptions) systemContext() *types.SystemContext {
+	return &types.SystemContext{
+		AuthFilePath:                opts.AuthFile,
+		DockerInsecureSkipTLSVerify: types.NewOptionalBool(!opts.TLSVerify),
+		RegistriesDirPath:           opts.registriesDir,
+	}
+}
+
+// ResolveDigest returns ref pinned to the digest of the manifest its tag points to in the registry.
+// A reference which already carries a digest is returned unchanged; the tag is kept next to the digest.
+// The digest is looked up in the mirror of ref, if opts.Mirrors has one, but ref keeps naming the source image.
+func ResolveDigest(ctx context.Context, ref Reference, opts ResolveOptions) (Reference, error) {
+	if ref.Digest != "" {
+		klog.V(4).InfoS("Image already pinned by digest", "image", ref.String())
+		return ref, nil
+	}
+
+	// Resolve against the mirror, the source registry may not be reachable from a disconnected network;
+	// a mirror serves the same digests
+	mirrored, _, err := opts.Mirrors.Rewrite(ref)
+	if err != nil {
+		return Reference{}, err
+	}
+	named, err := reference.ParseNormalizedNamed(mirrored.String())
+	if err != nil {
+		return Reference{}, err
+	}
+	imageRef, err := docker.NewReference(reference.TagNameOnly(named))
+	if err != nil {
+		return Reference{}, err
+	}
+
+	dgst, err := docker.GetDigest(ctx, opts.systemContext(), imageRef)
+	if err != nil {
+		return Reference{}, fmt.Errorf("failed to resolve the digest of %s: %w", mirrored.String(), err)
+	}
+
+	ref.Digest = dgst.String()
+	klog.V(2).InfoS("Resolved image digest", "image", ref.String())
+	return ref, nil
+}
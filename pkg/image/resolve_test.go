@@ -0,0 +1,169 @@
+package image
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+)
+
+// testManifest is the manifest served by the test registry, testDigest its digest
+const testManifest = `{"schemaVersion":2,"mediaType":"application/vnd.oci.image.manifest.v1+json",` +
+	`"config":{"mediaType":"application/vnd.oci.image.config.v1+json","digest":"sha256:44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a","size":2},` +
+	`"layers":[]}`
+
+var testDigest = digest.FromString(testManifest).String()
+
+// registryFile is a manifest or blob served by the test registry
+type registryFile struct {
+	mediaType string
+	data      string
+}
+
+// newTestRegistry starts a registry stand-in serving the manifest of org/driver:v1.0.0 by tag and digest,
+// requiring basic auth when user is set
+func newTestRegistry(t *testing.T, user, password string) string {
+	return newTestRegistryWithFiles(t, user, password, nil)
+}
+
+// newTestRegistryWithFiles starts a registry stand-in like newTestRegistry, also serving files by URL path
+func newTestRegistryWithFiles(t *testing.T, user, password string, files map[string]registryFile) string {
+	t.Helper()
+
+	manifest := registryFile{mediaType: "application/vnd.oci.image.manifest.v1+json", data: testManifest}
+	served := map[string]registryFile{
+		"/v2/org/driver/manifests/v1.0.0":        manifest,
+		"/v2/org/driver/manifests/" + testDigest: manifest,
+	}
+	for path, file := range files {
+		served[path] = file
+	}
+
+	registry := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user != "" {
+			if u, p, ok := r.BasicAuth(); !ok || u != user || p != password {
+				w.Header().Set("WWW-Authenticate", `Basic realm="test"`)
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+		}
+
+		if r.URL.Path == "/v2/" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		file, ok := served[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", file.mediaType)
+		w.Header().Set("Docker-Content-Digest", digest.FromString(file.data).String())
+		w.Header().Set("Content-Length", strconv.Itoa(len(file.data)))
+		w.WriteHeader(http.StatusOK)
+		if r.Method == http.MethodGet {
+			_, _ = w.Write([]byte(file.data))
+		}
+	}))
+	t.Cleanup(registry.Close)
+
+	return strings.TrimPrefix(registry.URL, "https://")
+}
+
+func TestResolveDigest(t *testing.T) {
+	host := newTestRegistry(t, "", "")
+
+	got, err := ResolveDigest(context.Background(), Reference{Image: host + "/org/driver", Tag: "v1.0.0"}, ResolveOptions{})
+	if err != nil {
+		t.Fatalf("ResolveDigest() failed: %v", err)
+	}
+	if want := host + "/org/driver:v1.0.0@" + testDigest; got.String() != want {
+		t.Errorf("ResolveDigest() = %q, want %q", got.String(), want)
+	}
+}
+
+func TestResolveDigestErrors(t *testing.T) {
+	host := newTestRegistry(t, "", "")
+
+	tests := []struct {
+		name string
+		ref  Reference
+		opts ResolveOptions
+	}{
+		{
+			name: "unknown tag",
+			ref:  Reference{Image: host + "/org/driver", Tag: "v2.0.0"},
+		},
+		{
+			name: "self-signed certificate with tls verification",
+			ref:  Reference{Image: host + "/org/driver", Tag: "v1.0.0"},
+			opts: ResolveOptions{TLSVerify: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ResolveDigest(context.Background(), tt.ref, tt.opts); err == nil {
+				t.Error("ResolveDigest() expected an error")
+			}
+		})
+	}
+}
+
+func TestResolveDigestAuthFile(t *testing.T) {
+	host := newTestRegistry(t, "user", "secret")
+	ref := Reference{Image: host + "/org/driver", Tag: "v1.0.0"}
+
+	authFile := filepath.Join(t.TempDir(), "auth.json")
+	auth := base64.StdEncoding.EncodeToString([]byte("user:secret"))
+	if err := os.WriteFile(authFile, []byte(fmt.Sprintf(`{"auths": {%q: {"auth": %q}}}`, host, auth)), 0o600); err != nil {
+		t.Fatalf("Failed to write auth file: %v", err)
+	}
+
+	if _, err := ResolveDigest(context.Background(), ref, ResolveOptions{AuthFile: filepath.Join(t.TempDir(), "missing.json")}); err == nil {
+		t.Error("Expected an error without credentials")
+	}
+
+	got, err := ResolveDigest(context.Background(), ref, ResolveOptions{AuthFile: authFile})
+	if err != nil {
+		t.Fatalf("ResolveDigest() failed: %v", err)
+	}
+	if got.Digest != testDigest {
+		t.Errorf("Expected digest %q, got %q", testDigest, got.Digest)
+	}
+}
+
+func TestResolveDigestPinned(t *testing.T) {
+	// No registry is contacted for a reference which is already pinned
+	ref := Reference{Image: "registry.invalid/org/driver", Tag: "v1.0.0", Digest: testDigest}
+	got, err := ResolveDigest(context.Background(), ref, ResolveOptions{TLSVerify: true})
+	if err != nil {
+		t.Fatalf("ResolveDigest() failed: %v", err)
+	}
+	if got != ref {
+		t.Errorf("ResolveDigest() = %v, want %v", got, ref)
+	}
+}
+
+func TestResolveDigestMirror(t *testing.T) {
+	host := newTestRegistry(t, "", "")
+	ref := Reference{Image: "registry.invalid/org/driver", Tag: "v1.0.0"}
+	opts := ResolveOptions{Mirrors: Mirrors{Mirrors: []Mirror{{Source: "registry.invalid", Destination: host}}}}
+
+	// The digest is looked up in the mirror, the reference keeps naming the source image
+	got, err := ResolveDigest(context.Background(), ref, opts)
+	if err != nil {
+		t.Fatalf("ResolveDigest() failed: %v", err)
+	}
+	if want := "registry.invalid/org/driver:v1.0.0@" + testDigest; got.String() != want {
+		t.Errorf("ResolveDigest() = %q, want %q", got.String(), want)
+	}
+}
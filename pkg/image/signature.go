@@ -0,0 +1,104 @@
+//go:build containers_image_openpgp
+
+package image
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/containers/image/v5/docker"
+	"github.com/containers/image/v5/docker/reference"
+	ctrimage "github.com/containers/image/v5/image"
+	"github.com/containers/image/v5/signature"
+	"k8s.io/klog/v2"
+)
+
+// VerifySignature checks the signatures of ref against the containers-policy.json(5) file at
+// policyPath, supporting both sigstore and simple signing requirements. ref must be pinned by
+// digest, so the verified image is the one deployed. The image is pulled from its mirror in
+// opts.Mirrors, if any, while the policy and the signatures apply to ref, the source image.
+func VerifySignature(ctx context.Context, ref Reference, policyPath string, opts ResolveOptions) error {
+	if ref.Digest == "" {
+		return fmt.Errorf("image %s must be pinned by digest to verify its signature", ref.String())
+	}
+
+	policy, err := signature.NewPolicyFromFile(policyPath)
+	if err != nil {
+		return fmt.Errorf("failed to read signature policy: %w", err)
+	}
+	policyContext, err := signature.NewPolicyContext(policy)
+	if err != nil {
+		return fmt.Errorf("invalid signature policy %s: %w", policyPath, err)
+	}
+	defer func() {
+		_ = policyContext.Destroy()
+	}()
+
+	// docker references carry either a tag or a digest, the digest identifies the deployed image
+	named, err := reference.ParseNamed(Reference{Image: ref.Image, Digest: ref.Digest}.String())
+	if err != nil {
+		return err
+	}
+	imageRef, err := docker.NewReference(named)
+	if err != nil {
+		return err
+	}
+
+	sys := opts.systemContext()
+	mirrored, rewritten, err := opts.Mirrors.Rewrite(ref)
+	if err != nil {
+		return err
+	}
+	if rewritten {
+		// Policies are scoped to the source repositories: keep evaluating them against ref and let
+		// containers/image pull it from the mirror, like a node configured with the mirror does
+		dir, err := os.MkdirTemp("", "dra-deployer-registries")
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = os.RemoveAll(dir)
+		}()
+		sys.SystemRegistriesConfPath, err = writeMirrorRegistriesConf(dir, ref, mirrored)
+		if err != nil {
+			return err
+		}
+		// Never read the drop-in files of the host configuration
+		sys.SystemRegistriesConfDirPath = filepath.Join(dir, "registries.conf.d")
+		klog.V(2).InfoS("Verifying image signature through registry mirror", "image", ref.String(), "mirror", mirrored.String())
+	}
+
+	src, err := imageRef.NewImageSource(ctx, sys)
+	if err != nil {
+		return fmt.Errorf("failed to access image %s: %w", ref.String(), err)
+	}
+	defer func() {
+		_ = src.Close()
+	}()
+
+	allowed, err := policyContext.IsRunningImageAllowed(ctx, ctrimage.UnparsedInstance(src, nil))
+	if err != nil {
+		return fmt.Errorf("signature verification of %s failed: %w", ref.String(), err)
+	}
+	if !allowed {
+		return fmt.Errorf("signature verification of %s failed: image rejected by policy %s", ref.String(), policyPath)
+	}
+
+	klog.V(2).InfoS("Verified image signature", "image", ref.String(), "policy", policyPath)
+	return nil
+}
+
+// writeMirrorRegistriesConf writes a containers-registries.conf(5) file in dir pulling the repository
+// of source from the repository of mirror, and returns its path
+func writeMirrorRegistriesConf(dir string, source, mirror Reference) (string, error) {
+	conf := fmt.Sprintf("[[registry]]\nprefix = %q\nlocation = %q\n\n[[registry.mirror]]\nlocation = %q\n",
+		source.Image, source.Image, mirror.Image)
+
+	path := filepath.Join(dir, "registries.conf")
+	if err := os.WriteFile(path, []byte(conf), 0o600); err != nil {
+		return "", fmt.Errorf("failed to write registries configuration: %w", err)
+	}
+	return path, nil
+}
@@ -0,0 +1,200 @@
+//go:build containers_image_openpgp
+
+package image
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+)
+
+func writePolicy(t *testing.T, policy string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "policy.json")
+	if err := os.WriteFile(path, []byte(policy), 0o600); err != nil {
+		t.Fatalf("Failed to write policy: %v", err)
+	}
+	return path
+}
+
+// newSigstoreTestRegistry starts a test registry which also serves a sigstore signature of
+// org/driver:v1.0.0 as an attachment. The signature in testdata was made with testdata/sigstore.key
+// for the identity registry.example.com/org/driver:v1.0.0, so it does not depend on the registry
+// address. It returns the registry host and the registries.d directory enabling sigstore attachments.
+func newSigstoreTestRegistry(t *testing.T) (string, string) {
+	t.Helper()
+
+	payload, err := os.ReadFile(filepath.Join("testdata", "sigstore-payload.json"))
+	if err != nil {
+		t.Fatalf("Failed to read signature payload: %v", err)
+	}
+	sig, err := os.ReadFile(filepath.Join("testdata", "sigstore-payload.sig"))
+	if err != nil {
+		t.Fatalf("Failed to read signature: %v", err)
+	}
+
+	payloadDigest := digest.FromBytes(payload)
+	attachment, err := json.Marshal(map[string]any{
+		"schemaVersion": 2,
+		"mediaType":     "application/vnd.oci.image.manifest.v1+json",
+		"config": map[string]any{
+			"mediaType": "application/vnd.oci.image.config.v1+json",
+			"digest":    digest.FromString("{}").String(),
+			"size":      2,
+		},
+		"layers": []any{map[string]any{
+			"mediaType":   "application/vnd.dev.cosign.simplesigning.v1+json",
+			"digest":      payloadDigest.String(),
+			"size":        len(payload),
+			"annotations": map[string]string{"dev.cosignproject.cosign/signature": strings.TrimSpace(string(sig))},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to encode signature attachment: %v", err)
+	}
+
+	registriesDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(registriesDir, "sigstore.yaml"), []byte("default-docker:\n  use-sigstore-attachments: true\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	host := newTestRegistryWithFiles(t, "", "", map[string]registryFile{
+		"/v2/org/driver/manifests/" + strings.Replace(testDigest, ":", "-", 1) + ".sig": {
+			mediaType: "application/vnd.oci.image.manifest.v1+json",
+			data:      string(attachment),
+		},
+		"/v2/org/driver/blobs/" + payloadDigest.String(): {
+			mediaType: "application/octet-stream",
+			data:      string(payload),
+		},
+	})
+	return host, registriesDir
+}
+
+func TestVerifySignature(t *testing.T) {
+	host := newTestRegistry(t, "", "")
+	ref := Reference{Image: host + "/org/driver", Tag: "v1.0.0", Digest: testDigest}
+
+	signedHost, registriesDir := newSigstoreTestRegistry(t)
+	signedRef := Reference{Image: signedHost + "/org/driver", Tag: "v1.0.0", Digest: testDigest}
+	publicKey, err := filepath.Abs(filepath.Join("testdata", "sigstore.pub"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		ref     Reference
+		policy  string
+		wantErr bool
+	}{
+		{
+			name:   "accept anything",
+			ref:    ref,
+			policy: `{"default": [{"type": "insecureAcceptAnything"}]}`,
+		},
+		{
+			name:    "reject",
+			ref:     ref,
+			policy:  `{"default": [{"type": "reject"}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "unsigned image with a simple signing requirement",
+			ref:     ref,
+			policy:  `{"default": [{"type": "signedBy", "keyType": "GPGKeys", "keyData": "dGVzdA=="}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "unsigned image with a sigstore requirement",
+			ref:     ref,
+			policy:  `{"default": [{"type": "sigstoreSigned", "keyData": "dGVzdA==", "signedIdentity": {"type": "matchRepository"}}]}`,
+			wantErr: true,
+		},
+		{
+			name: "sigstore signed image",
+			ref:  signedRef,
+			policy: `{"default": [{"type": "sigstoreSigned", "keyPath": "` + publicKey + `",
+				"signedIdentity": {"type": "exactRepository", "dockerRepository": "registry.example.com/org/driver"}}]}`,
+		},
+		{
+			name: "sigstore signature of another repository",
+			ref:  signedRef,
+			policy: `{"default": [{"type": "sigstoreSigned", "keyPath": "` + publicKey + `",
+				"signedIdentity": {"type": "matchRepository"}}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "sigstore signed image with a simple signing requirement",
+			ref:     signedRef,
+			policy:  `{"default": [{"type": "signedBy", "keyType": "GPGKeys", "keyData": "dGVzdA=="}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "image not pinned by digest",
+			ref:     Reference{Image: host + "/org/driver", Tag: "v1.0.0"},
+			policy:  `{"default": [{"type": "insecureAcceptAnything"}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "invalid policy",
+			ref:     ref,
+			policy:  `{"default": []}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifySignature(context.Background(), tt.ref, writePolicy(t, tt.policy), ResolveOptions{registriesDir: registriesDir})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("VerifySignature() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifySignatureMirror(t *testing.T) {
+	// Only the mirror is reachable, it serves the image of the source repository
+	host := newTestRegistry(t, "", "")
+	ref := Reference{Image: "registry.invalid/org/driver", Tag: "v1.0.0", Digest: testDigest}
+	opts := ResolveOptions{Mirrors: Mirrors{Mirrors: []Mirror{{Source: "registry.invalid", Destination: host}}}}
+
+	tests := []struct {
+		name    string
+		policy  string
+		wantErr bool
+	}{
+		{
+			name: "policy scoped to the source repository",
+			policy: `{"default": [{"type": "reject"}], "transports": {"docker": {
+				"registry.invalid/org/driver": [{"type": "insecureAcceptAnything"}]}}}`,
+		},
+		{
+			name: "policy rejecting the source repository",
+			policy: `{"default": [{"type": "insecureAcceptAnything"}], "transports": {"docker": {
+				"registry.invalid/org/driver": [{"type": "reject"}]}}}`,
+			wantErr: true,
+		},
+		{
+			name: "policy scoped to the mirror repository",
+			policy: `{"default": [{"type": "reject"}], "transports": {"docker": {
+				"` + host + `/org/driver": [{"type": "insecureAcceptAnything"}]}}}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifySignature(context.Background(), ref, writePolicy(t, tt.policy), opts)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("VerifySignature() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !containers_image_openpgp
+
+package image
+
+import (
+	"context"
+	"errors"
+)
+
+// VerifySignature is not available without the pure Go OpenPGP implementation of containers/image,
+// build with -tags containers_image_openpgp (as the Makefile does) to enable it
+func VerifySignature(ctx context.Context, ref Reference, policyPath string, opts ResolveOptions) error {
+	return errors.New("signature verification is not supported by this build, rebuild with -tags containers_image_openpgp")
+}
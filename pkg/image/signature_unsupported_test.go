@@ -0,0 +1,15 @@
+//go:build !containers_image_openpgp
+
+package image
+
+import (
+	"context"
+	"testing"
+)
+
+func TestVerifySignatureUnsupported(t *testing.T) {
+	ref := Reference{Image: "quay.io/org/driver", Digest: testDigest}
+	if err := VerifySignature(context.Background(), ref, "policy.json", ResolveOptions{}); err == nil {
+		t.Error("Expected an error from a build without signature verification support")
+	}
+}
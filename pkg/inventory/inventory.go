@@ -0,0 +1,264 @@
+package inventory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// namePrefix prefixes the release name in the name of an inventory ConfigMap
+	namePrefix = "dra-deployer-inventory-"
+
+	// objectsKey is the ConfigMap data key holding the JSON encoded inventory entries
+	objectsKey = "objects"
+	// signatureKey is the ConfigMap data key holding the JSON encoded signature verification
+	signatureKey = "signature"
+	// driverKey is the ConfigMap data key holding the DRA driver name the objects were applied with
+	driverKey = "driver"
+)
+
+// Metadata records how the objects of an inventory were applied
+type Metadata struct {
+	Driver    string     // Driver is the DRA driver name (driver.name) of the install, empty for inventories recorded before it was
+	Signature *Signature // Signature is the signature verification of the deployed image, nil if it was not verified
+}
+
+// Signature records the signature verification of the image deployed by the last apply
+type Signature struct {
+	Image      string      `json:"image"`
+	Mirror     string      `json:"mirror,omitempty"` // Mirror is the image the nodes pull from a registry mirror, empty without a mirror
+	Policy     string      `json:"policy"`
+	VerifiedAt metav1.Time `json:"verifiedAt"`
+}
+
+// Entry identifies an object applied by the deployer
+type Entry struct {
+	Group     string `json:"group"`
+	Version   string `json:"version"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+}
+
+// String returns a human readable identifier of the entry
+func (e Entry) String() string {
+	return fmt.Sprintf("%s/%s/%s", e.Kind, e.Namespace, e.Name)
+}
+
+// Object returns an unstructured object carrying only the identity of the entry,
+// suitable for Get and Delete calls
+func (e Entry) Object() *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(schema.GroupVersionKind{Group: e.Group, Version: e.Version, Kind: e.Kind})
+	obj.SetNamespace(e.Namespace)
+	obj.SetName(e.Name)
+	return obj
+}
+
+// id identifies the entry regardless of the API version it was applied with
+func (e Entry) id() string {
+	return fmt.Sprintf("%s/%s/%s/%s", e.Group, e.Kind, e.Namespace, e.Name)
+}
+
+// FromObjects returns the sorted inventory entries of objects
+func FromObjects(objects []*unstructured.Unstructured) []Entry {
+	entries := make([]Entry, 0, len(objects))
+	for _, obj := range objects {
+		gvk := obj.GroupVersionKind()
+		entries = append(entries, Entry{
+			Group:     gvk.Group,
+			Version:   gvk.Version,
+			Kind:      gvk.Kind,
+			Namespace: obj.GetNamespace(),
+			Name:      obj.GetName(),
+		})
+	}
+	sortEntries(entries)
+	return entries
+}
+
+// Stale returns the entries of previous which are not part of current
+func Stale(previous, current []Entry) []Entry {
+	keep := make(map[string]bool, len(current))
+	for _, e := range current {
+		keep[e.id()] = true
+	}
+
+	var stale []Entry
+	for _, e := range previous {
+		if !keep[e.id()] {
+			stale = append(stale, e)
+		}
+	}
+	return stale
+}
+
+// Merge returns the sorted union of a and b; entries of b win over the entries of a
+func Merge(a, b []Entry) []Entry {
+	merged := map[string]Entry{}
+	for _, e := range a {
+		merged[e.id()] = e
+	}
+	for _, e := range b {
+		merged[e.id()] = e
+	}
+
+	entries := make([]Entry, 0, len(merged))
+	for _, e := range merged {
+		entries = append(entries, e)
+	}
+	sortEntries(entries)
+	return entries
+}
+
+// Name returns the name of the ConfigMap holding the inventory of release in the install namespace
+func Name(release string) string {
+	return namePrefix + release
+}
+
+// Load reads the inventory of release stored in namespace. The returned bool is false if no
+// inventory exists, e.g. for installs made before it was recorded.
+func Load(ctx context.Context, cli client.Client, namespace, release string) ([]Entry, bool, error) {
+	cm, err := get(ctx, cli, namespace, release)
+	if err != nil || cm == nil {
+		return nil, false, err
+	}
+
+	entries, err := decode(cm.Data[objectsKey])
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to decode inventory %s/%s: %w", namespace, cm.Name, err)
+	}
+	return entries, true, nil
+}
+
+// LoadMetadata reads the metadata recorded in the inventory of release, the one Load reads.
+// It returns empty metadata if no inventory exists.
+func LoadMetadata(ctx context.Context, cli client.Client, namespace, release string) (Metadata, error) {
+	cm, err := get(ctx, cli, namespace, release)
+	if err != nil || cm == nil {
+		return Metadata{}, err
+	}
+
+	meta := Metadata{Driver: cm.Data[driverKey]}
+	if data, ok := cm.Data[signatureKey]; ok {
+		meta.Signature = &Signature{}
+		if err := json.Unmarshal([]byte(data), meta.Signature); err != nil {
+			return Metadata{}, fmt.Errorf("failed to decode signature verification %s/%s: %w", namespace, cm.Name, err)
+		}
+	}
+	return meta, nil
+}
+
+// get returns the inventory ConfigMap of release, or nil if it does not exist
+func get(ctx context.Context, cli client.Client, namespace, release string) (*corev1.ConfigMap, error) {
+	cm := &corev1.ConfigMap{}
+	err := cli.Get(ctx, client.ObjectKey{Namespace: namespace, Name: Name(release)}, cm)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			klog.V(4).InfoS("No inventory found", "namespace", namespace, "release", release)
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get inventory: %w", err)
+	}
+	return cm, nil
+}
+
+// Releases returns the releases with an inventory in namespace, sorted by name
+func Releases(ctx context.Context, cli client.Client, namespace string) ([]string, error) {
+	cms := &corev1.ConfigMapList{}
+	err := cli.List(ctx, cms, client.InNamespace(namespace), client.MatchingLabels{"app.kubernetes.io/managed-by": "dra-deployer"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list inventories: %w", err)
+	}
+
+	var releases []string
+	for _, cm := range cms.Items {
+		release := cm.Labels["app.kubernetes.io/instance"]
+		if release != "" && cm.Name == Name(release) {
+			releases = append(releases, release)
+		}
+	}
+	sort.Strings(releases)
+	return releases, nil
+}
+
+// Object returns an unstructured object carrying only the identity of the inventory ConfigMap
+// of release in namespace
+func Object(namespace, release string) *unstructured.Unstructured {
+	cm := &unstructured.Unstructured{}
+	cm.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("ConfigMap"))
+	cm.SetNamespace(namespace)
+	cm.SetName(Name(release))
+	return cm
+}
+
+// Save stores entries and meta as the inventory of release in namespace, replacing any previous inventory
+func Save(ctx context.Context, cli client.Client, namespace, release string, entries []Entry, meta Metadata, opts ...client.ApplyOption) error {
+	data, err := encode(entries)
+	if err != nil {
+		return fmt.Errorf("failed to encode inventory: %w", err)
+	}
+	cmData := map[string]string{objectsKey: data}
+	if meta.Driver != "" {
+		cmData[driverKey] = meta.Driver
+	}
+	if meta.Signature != nil {
+		sigData, err := json.Marshal(meta.Signature)
+		if err != nil {
+			return fmt.Errorf("failed to encode signature verification: %w", err)
+		}
+		cmData[signatureKey] = string(sigData)
+	}
+
+	cm := Object(namespace, release)
+	cm.SetLabels(map[string]string{
+		"app.kubernetes.io/managed-by": "dra-deployer",
+		"app.kubernetes.io/instance":   release,
+	})
+	if err := unstructured.SetNestedStringMap(cm.Object, cmData, "data"); err != nil {
+		return err
+	}
+
+	err = cli.Apply(ctx, client.ApplyConfigurationFromUnstructured(cm), opts...)
+	if err != nil {
+		return fmt.Errorf("failed to save inventory: %w", err)
+	}
+	klog.V(4).InfoS("Saved inventory", "namespace", namespace, "release", release, "objects", len(entries))
+	return nil
+}
+
+func encode(entries []Entry) (string, error) {
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func decode(data string) ([]Entry, error) {
+	var entries []Entry
+	if data == "" {
+		return entries, nil
+	}
+	if err := json.Unmarshal([]byte(data), &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func sortEntries(entries []Entry) {
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].id() < entries[j].id()
+	})
+}
@@ -0,0 +1,112 @@
+package inventory
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newObject(apiVersion, kind, namespace, name string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(apiVersion)
+	obj.SetKind(kind)
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	return obj
+}
+
+func TestFromObjects(t *testing.T) {
+	objects := []*unstructured.Unstructured{
+		newObject("apps/v1", "DaemonSet", "dra", "plugin"),
+		newObject("rbac.authorization.k8s.io/v1", "ClusterRole", "", "role"),
+		newObject("v1", "ServiceAccount", "dra", "sa"),
+	}
+
+	want := []Entry{
+		{Group: "", Version: "v1", Kind: "ServiceAccount", Namespace: "dra", Name: "sa"},
+		{Group: "apps", Version: "v1", Kind: "DaemonSet", Namespace: "dra", Name: "plugin"},
+		{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole", Name: "role"},
+	}
+
+	got := FromObjects(objects)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FromObjects() = %v, want %v", got, want)
+	}
+}
+
+func TestStale(t *testing.T) {
+	previous := []Entry{
+		{Group: "apps", Version: "v1", Kind: "DaemonSet", Namespace: "dra", Name: "plugin"},
+		{Group: "security.openshift.io", Version: "v1", Kind: "SecurityContextConstraints", Name: "scc"},
+		{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole", Name: "old-role"},
+	}
+	current := []Entry{
+		// A different API version of the same object is not stale
+		{Group: "apps", Version: "v2", Kind: "DaemonSet", Namespace: "dra", Name: "plugin"},
+		{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole", Name: "new-role"},
+	}
+
+	want := []Entry{
+		{Group: "security.openshift.io", Version: "v1", Kind: "SecurityContextConstraints", Name: "scc"},
+		{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole", Name: "old-role"},
+	}
+
+	got := Stale(previous, current)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Stale() = %v, want %v", got, want)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	a := []Entry{
+		{Group: "apps", Version: "v1", Kind: "DaemonSet", Namespace: "dra", Name: "plugin"},
+		{Group: "security.openshift.io", Version: "v1", Kind: "SecurityContextConstraints", Name: "scc"},
+	}
+	b := []Entry{
+		{Group: "apps", Version: "v2", Kind: "DaemonSet", Namespace: "dra", Name: "plugin"},
+	}
+
+	want := []Entry{
+		{Group: "apps", Version: "v2", Kind: "DaemonSet", Namespace: "dra", Name: "plugin"},
+		{Group: "security.openshift.io", Version: "v1", Kind: "SecurityContextConstraints", Name: "scc"},
+	}
+
+	got := Merge(a, b)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Merge() = %v, want %v", got, want)
+	}
+}
+
+func TestEncodeDecode(t *testing.T) {
+	entries := []Entry{
+		{Group: "", Version: "v1", Kind: "ServiceAccount", Namespace: "dra", Name: "sa"},
+		{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole", Name: "role"},
+	}
+
+	data, err := encode(entries)
+	if err != nil {
+		t.Fatalf("encode() failed: %v", err)
+	}
+
+	got, err := decode(data)
+	if err != nil {
+		t.Fatalf("decode() failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, entries) {
+		t.Errorf("decode(encode()) = %v, want %v", got, entries)
+	}
+
+	if _, err := decode("not json"); err == nil {
+		t.Error("decode() expected error for malformed data, got nil")
+	}
+}
+
+func TestName(t *testing.T) {
+	if got := Name("staging"); got != "dra-deployer-inventory-staging" {
+		t.Errorf("Name(staging) = %q", got)
+	}
+	if got := Object("dra", "canary"); got.GetNamespace() != "dra" || got.GetName() != "dra-deployer-inventory-canary" || got.GetKind() != "ConfigMap" {
+		t.Errorf("Unexpected inventory object %s %s/%s", got.GetKind(), got.GetNamespace(), got.GetName())
+	}
+}
@@ -0,0 +1,154 @@
+// Package manifests writes rendered objects to the filesystem.
+package manifests
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// ClusterDir is the subdirectory holding the cluster-scoped objects
+	ClusterDir = "cluster"
+	// NamespacedDir is the subdirectory holding the namespaced objects
+	NamespacedDir = "namespaced"
+	// KustomizationFile is the name of the generated Kustomization
+	KustomizationFile = "kustomization.yaml"
+
+	kustomizationHeader = "# Code generated by dra-deployer render --output-dir. DO NOT EDIT.\n"
+)
+
+// kustomization is the subset of a kustomize.config.k8s.io Kustomization written by WriteDir
+type kustomization struct {
+	APIVersion string   `json:"apiVersion"`
+	Kind       string   `json:"kind"`
+	Resources  []string `json:"resources"`
+}
+
+// WriteDir writes every object to its own <kind>-<name>.yaml file below dir, cluster-scoped
+// objects to ClusterDir and namespaced ones to NamespacedDir, and a kustomization.yaml listing
+// them, so the directory can be used as a Kustomize base. The object files listed in the
+// kustomization.yaml of a previous run are removed first, other files are left alone. A non-empty
+// directory without such a kustomization.yaml is refused unless force is set, in which case files
+// are overwritten but none is removed. It returns the paths of the written object files relative to dir.
+func WriteDir(dir string, objects []*unstructured.Unstructured, force bool) ([]string, error) {
+	if err := cleanDir(dir, force); err != nil {
+		return nil, err
+	}
+	for _, sub := range []string{ClusterDir, NamespacedDir} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create directory %s: %w", filepath.Join(dir, sub), err)
+		}
+	}
+
+	resources := make([]string, 0, len(objects))
+	written := make(map[string]string, len(objects))
+	for _, obj := range objects {
+		path := FileName(obj)
+		if prev, ok := written[path]; ok {
+			return nil, fmt.Errorf("objects %s and %s would both be written to %s", prev, objectRef(obj), path)
+		}
+		written[path] = objectRef(obj)
+
+		data, err := yaml.Marshal(obj)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal %s to YAML: %w", objectRef(obj), err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, path), data, 0o644); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		klog.V(2).InfoS("Wrote manifest", "object", objectRef(obj), "file", path)
+		resources = append(resources, path)
+	}
+
+	data, err := yaml.Marshal(kustomization{
+		APIVersion: "kustomize.config.k8s.io/v1beta1",
+		Kind:       "Kustomization",
+		Resources:  resources,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s: %w", KustomizationFile, err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, KustomizationFile), append([]byte(kustomizationHeader), data...), 0o644); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", KustomizationFile, err)
+	}
+
+	return resources, nil
+}
+
+// FileName returns the path of the file obj is written to by WriteDir, relative to the output directory
+func FileName(obj *unstructured.Unstructured) string {
+	sub := ClusterDir
+	if obj.GetNamespace() != "" {
+		sub = NamespacedDir
+	}
+	name := strings.ToLower(obj.GetKind()) + "-" + strings.NewReplacer("/", "-", ":", "-").Replace(obj.GetName()) + ".yaml"
+	return filepath.ToSlash(filepath.Join(sub, name))
+}
+
+// cleanDir removes the object files listed in the kustomization.yaml WriteDir left in dir
+func cleanDir(dir string, force bool) error {
+	previous, err := readKustomization(dir)
+	if err != nil {
+		return err
+	}
+
+	if previous == nil {
+		entries, err := os.ReadDir(dir)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to read directory %s: %w", dir, err)
+		}
+		if len(entries) > 0 && !force {
+			return fmt.Errorf("directory %s is not empty and holds no %s written by dra-deployer, pass --force to write into it", dir, KustomizationFile)
+		}
+		return nil
+	}
+
+	for _, file := range previous.Resources {
+		// Only remove what WriteDir could have written, never a path edited to point elsewhere
+		if path.Clean(file) != file || (path.Dir(file) != ClusterDir && path.Dir(file) != NamespacedDir) {
+			klog.V(2).InfoS("Keeping file not written by dra-deployer", "file", file)
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, filepath.FromSlash(file))); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove stale manifest %s: %w", file, err)
+		}
+	}
+	return nil
+}
+
+// readKustomization returns the kustomization.yaml written by WriteDir to dir, or nil if there
+// is none
+func readKustomization(dir string) (*kustomization, error) {
+	data, err := os.ReadFile(filepath.Join(dir, KustomizationFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", KustomizationFile, err)
+	}
+	if !bytes.HasPrefix(data, []byte(kustomizationHeader)) {
+		return nil, nil
+	}
+
+	k := &kustomization{}
+	if err := yaml.Unmarshal(data, k); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", KustomizationFile, err)
+	}
+	return k, nil
+}
+
+func objectRef(obj *unstructured.Unstructured) string {
+	if obj.GetNamespace() == "" {
+		return obj.GetKind() + "/" + obj.GetName()
+	}
+	return obj.GetKind() + "/" + obj.GetNamespace() + "/" + obj.GetName()
+}
@@ -0,0 +1,143 @@
+package manifests
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"sigs.k8s.io/yaml"
+)
+
+func newObject(apiVersion, kind, namespace, name string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(apiVersion)
+	obj.SetKind(kind)
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	return obj
+}
+
+func TestFileName(t *testing.T) {
+	tests := []struct {
+		obj      *unstructured.Unstructured
+		expected string
+	}{
+		{obj: newObject("apps/v1", "DaemonSet", "dra", "plugin"), expected: "namespaced/daemonset-plugin.yaml"},
+		{obj: newObject("rbac.authorization.k8s.io/v1", "ClusterRole", "", "plugin-role"), expected: "cluster/clusterrole-plugin-role.yaml"},
+		{obj: newObject("resource.k8s.io/v1", "DeviceClass", "", "dra.memory"), expected: "cluster/deviceclass-dra.memory.yaml"},
+		{obj: newObject("rbac.authorization.k8s.io/v1", "ClusterRole", "", "system:plugin"), expected: "cluster/clusterrole-system-plugin.yaml"},
+	}
+
+	for _, tt := range tests {
+		if got := FileName(tt.obj); got != tt.expected {
+			t.Errorf("FileName(%s) = %q, want %q", tt.obj.GetName(), got, tt.expected)
+		}
+	}
+}
+
+func TestWriteDir(t *testing.T) {
+	dir := t.TempDir()
+
+	// A manifest written by a previous render must be removed
+	if _, err := WriteDir(dir, []*unstructured.Unstructured{newObject("rbac.authorization.k8s.io/v1", "ClusterRole", "", "old")}, false); err != nil {
+		t.Fatalf("WriteDir failed: %v", err)
+	}
+	stale := filepath.Join(dir, ClusterDir, "clusterrole-old.yaml")
+
+	// A file added by the user must be kept
+	patch := filepath.Join(dir, ClusterDir, "patch-role.yaml")
+	if err := os.WriteFile(patch, []byte("kind: ClusterRole\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	objects := []*unstructured.Unstructured{
+		newObject("rbac.authorization.k8s.io/v1", "ClusterRole", "", "plugin-role"),
+		newObject("apps/v1", "DaemonSet", "dra", "plugin"),
+	}
+
+	files, err := WriteDir(dir, objects, false)
+	if err != nil {
+		t.Fatalf("WriteDir failed: %v", err)
+	}
+
+	expected := []string{"cluster/clusterrole-plugin-role.yaml", "namespaced/daemonset-plugin.yaml"}
+	if !reflect.DeepEqual(files, expected) {
+		t.Errorf("Expected files %v, got %v", expected, files)
+	}
+
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("Expected stale manifest to be removed, got %v", err)
+	}
+	if _, err := os.Stat(patch); err != nil {
+		t.Errorf("Expected the file added by the user to be kept, got %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "namespaced", "daemonset-plugin.yaml"))
+	if err != nil {
+		t.Fatalf("Failed to read manifest: %v", err)
+	}
+	obj := &unstructured.Unstructured{}
+	if err := yaml.Unmarshal(data, &obj.Object); err != nil {
+		t.Fatalf("Failed to parse manifest: %v", err)
+	}
+	if obj.GetKind() != "DaemonSet" || obj.GetNamespace() != "dra" || obj.GetName() != "plugin" {
+		t.Errorf("Unexpected manifest content: %s", data)
+	}
+
+	data, err = os.ReadFile(filepath.Join(dir, KustomizationFile))
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", KustomizationFile, err)
+	}
+	k := kustomization{}
+	if err := yaml.Unmarshal(data, &k); err != nil {
+		t.Fatalf("Failed to parse %s: %v", KustomizationFile, err)
+	}
+	if k.Kind != "Kustomization" || !reflect.DeepEqual(k.Resources, expected) {
+		t.Errorf("Unexpected kustomization: %s", data)
+	}
+}
+
+func TestWriteDirConflict(t *testing.T) {
+	objects := []*unstructured.Unstructured{
+		newObject("v1", "ServiceAccount", "a", "plugin"),
+		newObject("v1", "ServiceAccount", "b", "plugin"),
+	}
+
+	if _, err := WriteDir(t.TempDir(), objects, false); err == nil {
+		t.Error("Expected an error for objects written to the same file")
+	}
+}
+
+func TestWriteDirNotEmpty(t *testing.T) {
+	dir := t.TempDir()
+	own := filepath.Join(dir, "deployment.yaml")
+	if err := os.WriteFile(own, []byte("kind: Deployment\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// A kustomization.yaml not written by dra-deployer lists nothing to remove
+	if err := os.WriteFile(filepath.Join(dir, KustomizationFile), []byte("resources:\n- deployment.yaml\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	objects := []*unstructured.Unstructured{newObject("apps/v1", "DaemonSet", "dra", "plugin")}
+
+	if _, err := WriteDir(dir, objects, false); err == nil {
+		t.Fatal("Expected a non-empty directory to be refused")
+	}
+	if _, err := os.Stat(filepath.Join(dir, NamespacedDir)); !os.IsNotExist(err) {
+		t.Errorf("Expected nothing to be written to a refused directory, got %v", err)
+	}
+
+	if _, err := WriteDir(dir, objects, true); err != nil {
+		t.Fatalf("WriteDir with force failed: %v", err)
+	}
+	if _, err := os.Stat(own); err != nil {
+		t.Errorf("Expected the existing file to be kept, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "namespaced", "daemonset-plugin.yaml")); err != nil {
+		t.Errorf("Expected the manifest to be written, got %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+package manifests
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Output formats supported by Print
+const (
+	OutputYAML     = "yaml"
+	OutputJSON     = "json"
+	OutputJSONList = "json-list"
+)
+
+// Print writes objects to w in the given output format: a multi-document YAML stream,
+// a stream of JSON objects, or a single v1 List object holding all of them
+func Print(w io.Writer, objects []*unstructured.Unstructured, format string) error {
+	switch format {
+	case OutputYAML, "":
+		for i, obj := range objects {
+			if i > 0 {
+				if _, err := fmt.Fprintln(w, "---"); err != nil {
+					return err
+				}
+			}
+			data, err := yaml.Marshal(obj)
+			if err != nil {
+				return fmt.Errorf("failed to marshal manifest to YAML: %w", err)
+			}
+			if _, err := w.Write(data); err != nil {
+				return err
+			}
+		}
+		return nil
+	case OutputJSON:
+		for _, obj := range objects {
+			if err := printJSON(w, obj.Object); err != nil {
+				return err
+			}
+		}
+		return nil
+	case OutputJSONList:
+		items := make([]any, 0, len(objects))
+		for _, obj := range objects {
+			items = append(items, obj.Object)
+		}
+		return printJSON(w, map[string]any{
+			"apiVersion": "v1",
+			"kind":       "List",
+			"metadata":   map[string]any{},
+			"items":      items,
+		})
+	default:
+		return fmt.Errorf("unsupported output format %q, must be one of: %s, %s, %s", format, OutputYAML, OutputJSON, OutputJSONList)
+	}
+}
+
+func printJSON(w io.Writer, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal manifest to JSON: %w", err)
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
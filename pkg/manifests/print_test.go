@@ -0,0 +1,76 @@
+package manifests
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func testObjects() []*unstructured.Unstructured {
+	return []*unstructured.Unstructured{
+		newObject("v1", "ServiceAccount", "dra", "plugin"),
+		newObject("apps/v1", "DaemonSet", "dra", "plugin"),
+	}
+}
+
+func TestPrintYAML(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Print(&buf, testObjects(), OutputYAML); err != nil {
+		t.Fatalf("Print failed: %v", err)
+	}
+
+	docs := strings.Split(buf.String(), "---\n")
+	if len(docs) != 2 {
+		t.Fatalf("Expected 2 YAML documents, got %d:\n%s", len(docs), buf.String())
+	}
+	if !strings.Contains(docs[0], "kind: ServiceAccount") || !strings.Contains(docs[1], "kind: DaemonSet") {
+		t.Errorf("Unexpected YAML output:\n%s", buf.String())
+	}
+}
+
+func TestPrintJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Print(&buf, testObjects(), OutputJSON); err != nil {
+		t.Fatalf("Print failed: %v", err)
+	}
+
+	decoder := json.NewDecoder(&buf)
+	var kinds []string
+	for decoder.More() {
+		obj := map[string]any{}
+		if err := decoder.Decode(&obj); err != nil {
+			t.Fatalf("Failed to decode JSON stream: %v", err)
+		}
+		kinds = append(kinds, obj["kind"].(string))
+	}
+	if strings.Join(kinds, ",") != "ServiceAccount,DaemonSet" {
+		t.Errorf("Unexpected kinds in JSON stream: %v", kinds)
+	}
+}
+
+func TestPrintJSONList(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Print(&buf, testObjects(), OutputJSONList); err != nil {
+		t.Fatalf("Print failed: %v", err)
+	}
+
+	list := &unstructured.UnstructuredList{}
+	if err := list.UnmarshalJSON(buf.Bytes()); err != nil {
+		t.Fatalf("Failed to decode List: %v", err)
+	}
+	if list.GetAPIVersion() != "v1" || list.GetKind() != "List" {
+		t.Errorf("Expected a v1 List, got %s %s", list.GetAPIVersion(), list.GetKind())
+	}
+	if len(list.Items) != 2 || list.Items[1].GetKind() != "DaemonSet" {
+		t.Errorf("Unexpected List items: %v", list.Items)
+	}
+}
+
+func TestPrintUnsupportedFormat(t *testing.T) {
+	if err := Print(&bytes.Buffer{}, testObjects(), "xml"); err == nil {
+		t.Error("Expected an error for an unsupported output format")
+	}
+}
@@ -0,0 +1,81 @@
+// Package openshift contains helpers for the OpenShift specific cluster configuration DRA depends on.
+package openshift
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	configv1 "github.com/openshift/api/config/v1"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// FeatureGateName is the name of the cluster-wide FeatureGate object
+	FeatureGateName = "cluster"
+	// DRAFeatureGate is the feature gate enabling Dynamic Resource Allocation
+	DRAFeatureGate configv1.FeatureGateName = "DynamicResourceAllocation"
+)
+
+// GetFeatureGate returns the cluster-wide FeatureGate object
+func GetFeatureGate(ctx context.Context, cli client.Client) (*configv1.FeatureGate, error) {
+	fg := &configv1.FeatureGate{}
+	if err := cli.Get(ctx, client.ObjectKey{Name: FeatureGateName}, fg); err != nil {
+		return nil, fmt.Errorf("failed to get FeatureGate %s: %w", FeatureGateName, err)
+	}
+	return fg, nil
+}
+
+// DRAEnabled reports whether the FeatureGate enables Dynamic Resource Allocation, either
+// through the selected featureSet or through a custom feature gate list
+func DRAEnabled(fg *configv1.FeatureGate) bool {
+	// The status lists the gates rendered for each cluster version, it covers
+	// releases where DRA is enabled in the default featureSet
+	for _, details := range fg.Status.FeatureGates {
+		for _, attrs := range details.Enabled {
+			if attrs.Name == DRAFeatureGate {
+				return true
+			}
+		}
+	}
+
+	switch fg.Spec.FeatureSet {
+	case configv1.TechPreviewNoUpgrade, configv1.DevPreviewNoUpgrade:
+		return true
+	case configv1.CustomNoUpgrade:
+		return fg.Spec.CustomNoUpgrade != nil && slices.Contains(fg.Spec.CustomNoUpgrade.Enabled, DRAFeatureGate)
+	default:
+		return false
+	}
+}
+
+// EnableDRA enables Dynamic Resource Allocation in the FeatureGate. A CustomNoUpgrade featureSet
+// keeps its custom gates and gets DynamicResourceAllocation added to them, the default featureSet
+// is set to TechPreviewNoUpgrade. Other featureSets are refused, since switching them would change
+// the enabled gates. Both featureSets cannot be reverted and the cluster can no longer be upgraded.
+func EnableDRA(ctx context.Context, cli client.Client, fg *configv1.FeatureGate) error {
+	patch := client.MergeFrom(fg.DeepCopy())
+	switch fg.Spec.FeatureSet {
+	case configv1.CustomNoUpgrade:
+		if fg.Spec.CustomNoUpgrade == nil {
+			fg.Spec.CustomNoUpgrade = &configv1.CustomFeatureGates{}
+		}
+		custom := fg.Spec.CustomNoUpgrade
+		custom.Disabled = slices.DeleteFunc(custom.Disabled, func(name configv1.FeatureGateName) bool {
+			return name == DRAFeatureGate
+		})
+		if !slices.Contains(custom.Enabled, DRAFeatureGate) {
+			custom.Enabled = append(custom.Enabled, DRAFeatureGate)
+		}
+	case configv1.Default:
+		fg.Spec.FeatureSet = configv1.TechPreviewNoUpgrade
+	default:
+		return fmt.Errorf("cannot enable %s in featureSet %s, only the default and %s featureSets can be changed",
+			DRAFeatureGate, fg.Spec.FeatureSet, configv1.CustomNoUpgrade)
+	}
+	if err := cli.Patch(ctx, fg, patch); err != nil {
+		return fmt.Errorf("failed to patch FeatureGate %s: %w", FeatureGateName, err)
+	}
+	return nil
+}
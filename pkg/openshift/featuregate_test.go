@@ -0,0 +1,165 @@
+package openshift
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestDRAEnabled(t *testing.T) {
+	tests := []struct {
+		name   string
+		fg     configv1.FeatureGate
+		expect bool
+	}{
+		{
+			name:   "default featureSet",
+			fg:     configv1.FeatureGate{},
+			expect: false,
+		},
+		{
+			name: "TechPreviewNoUpgrade featureSet",
+			fg: configv1.FeatureGate{
+				Spec: configv1.FeatureGateSpec{
+					FeatureGateSelection: configv1.FeatureGateSelection{FeatureSet: configv1.TechPreviewNoUpgrade},
+				},
+			},
+			expect: true,
+		},
+		{
+			name: "CustomNoUpgrade with DRA enabled",
+			fg: configv1.FeatureGate{
+				Spec: configv1.FeatureGateSpec{
+					FeatureGateSelection: configv1.FeatureGateSelection{
+						FeatureSet: configv1.CustomNoUpgrade,
+						CustomNoUpgrade: &configv1.CustomFeatureGates{
+							Enabled: []configv1.FeatureGateName{DRAFeatureGate},
+						},
+					},
+				},
+			},
+			expect: true,
+		},
+		{
+			name: "CustomNoUpgrade without DRA",
+			fg: configv1.FeatureGate{
+				Spec: configv1.FeatureGateSpec{
+					FeatureGateSelection: configv1.FeatureGateSelection{
+						FeatureSet: configv1.CustomNoUpgrade,
+						CustomNoUpgrade: &configv1.CustomFeatureGates{
+							Disabled: []configv1.FeatureGateName{DRAFeatureGate},
+						},
+					},
+				},
+			},
+			expect: false,
+		},
+		{
+			name: "enabled in the status of the default featureSet",
+			fg: configv1.FeatureGate{
+				Status: configv1.FeatureGateStatus{
+					FeatureGates: []configv1.FeatureGateDetails{
+						{
+							Version: "4.21.0",
+							Enabled: []configv1.FeatureGateAttributes{{Name: DRAFeatureGate}},
+						},
+					},
+				},
+			},
+			expect: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DRAEnabled(&tt.fg); got != tt.expect {
+				t.Errorf("DRAEnabled() = %v, want %v", got, tt.expect)
+			}
+		})
+	}
+}
+
+func TestEnableDRA(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = configv1.Install(scheme)
+
+	tests := []struct {
+		name      string
+		selection configv1.FeatureGateSelection
+		expect    configv1.FeatureGateSelection
+		expectErr bool
+	}{
+		{
+			name:      "default featureSet",
+			selection: configv1.FeatureGateSelection{},
+			expect:    configv1.FeatureGateSelection{FeatureSet: configv1.TechPreviewNoUpgrade},
+		},
+		{
+			name: "CustomNoUpgrade keeps its gates",
+			selection: configv1.FeatureGateSelection{
+				FeatureSet: configv1.CustomNoUpgrade,
+				CustomNoUpgrade: &configv1.CustomFeatureGates{
+					Enabled:  []configv1.FeatureGateName{"NodeSwap"},
+					Disabled: []configv1.FeatureGateName{DRAFeatureGate, "UserNamespacesSupport"},
+				},
+			},
+			expect: configv1.FeatureGateSelection{
+				FeatureSet: configv1.CustomNoUpgrade,
+				CustomNoUpgrade: &configv1.CustomFeatureGates{
+					Enabled:  []configv1.FeatureGateName{"NodeSwap", DRAFeatureGate},
+					Disabled: []configv1.FeatureGateName{"UserNamespacesSupport"},
+				},
+			},
+		},
+		{
+			name:      "other featureSet",
+			selection: configv1.FeatureGateSelection{FeatureSet: "LatencySensitive"},
+			expect:    configv1.FeatureGateSelection{FeatureSet: "LatencySensitive"},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fg := &configv1.FeatureGate{
+				ObjectMeta: metav1.ObjectMeta{Name: FeatureGateName},
+				Spec:       configv1.FeatureGateSpec{FeatureGateSelection: tt.selection},
+			}
+			cli := fake.NewClientBuilder().WithScheme(scheme).WithObjects(fg.DeepCopy()).Build()
+
+			err := EnableDRA(context.Background(), cli, fg)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("EnableDRA() error = %v, expectErr %v", err, tt.expectErr)
+			}
+
+			live := &configv1.FeatureGate{}
+			if err := cli.Get(context.Background(), client.ObjectKey{Name: FeatureGateName}, live); err != nil {
+				t.Fatalf("Get() failed: %v", err)
+			}
+			got := live.Spec.FeatureGateSelection
+			if got.FeatureSet != tt.expect.FeatureSet {
+				t.Errorf("Expected featureSet %q, got %q", tt.expect.FeatureSet, got.FeatureSet)
+			}
+			if (got.CustomNoUpgrade == nil) != (tt.expect.CustomNoUpgrade == nil) {
+				t.Fatalf("Expected customNoUpgrade %+v, got %+v", tt.expect.CustomNoUpgrade, got.CustomNoUpgrade)
+			}
+			if tt.expect.CustomNoUpgrade != nil {
+				if !slices.Equal(got.CustomNoUpgrade.Enabled, tt.expect.CustomNoUpgrade.Enabled) ||
+					!slices.Equal(got.CustomNoUpgrade.Disabled, tt.expect.CustomNoUpgrade.Disabled) {
+					t.Errorf("Expected customNoUpgrade %+v, got %+v", tt.expect.CustomNoUpgrade, got.CustomNoUpgrade)
+				}
+			}
+			if !tt.expectErr && !DRAEnabled(live) {
+				t.Errorf("Expected %s to be enabled, got %+v", DRAFeatureGate, got)
+			}
+		})
+	}
+}
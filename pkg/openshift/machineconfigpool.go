@@ -0,0 +1,151 @@
+package openshift
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// DefaultMachineConfigPoolTimeout is the default time to wait for the MachineConfigPools to roll
+	// out, rolling a new MachineConfig reboots every node one after another
+	DefaultMachineConfigPoolTimeout = 60 * time.Minute
+
+	machineConfigPoolPollInterval = 10 * time.Second
+)
+
+// MachineConfigPools are read as unstructured objects, the machineconfiguration.openshift.io
+// types are not part of the openshift/api config and security packages
+var machineConfigPoolListGVK = schema.GroupVersionKind{
+	Group:   "machineconfiguration.openshift.io",
+	Version: "v1",
+	Kind:    "MachineConfigPoolList",
+}
+
+// MachineConfigPoolState summarizes the rollout state of a MachineConfigPool
+type MachineConfigPoolState struct {
+	Name string
+	// RenderedConfig is the rendered MachineConfig the pool is rolling out
+	RenderedConfig string
+	Paused         bool
+	Updated        bool
+	Degraded       bool
+	MachineCount   int64
+	UpdatedCount   int64
+}
+
+func (s MachineConfigPoolState) String() string {
+	switch {
+	case s.Paused:
+		return "paused"
+	case s.Degraded:
+		return "degraded"
+	case s.Updated:
+		return fmt.Sprintf("updated (%d/%d machines)", s.UpdatedCount, s.MachineCount)
+	default:
+		return fmt.Sprintf("updating (%d/%d machines)", s.UpdatedCount, s.MachineCount)
+	}
+}
+
+// ListMachineConfigPools returns the state of every MachineConfigPool, sorted by name
+func ListMachineConfigPools(ctx context.Context, cli client.Client) ([]MachineConfigPoolState, error) {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(machineConfigPoolListGVK)
+	if err := cli.List(ctx, list); err != nil {
+		return nil, fmt.Errorf("failed to list MachineConfigPools: %w", err)
+	}
+
+	states := make([]MachineConfigPoolState, 0, len(list.Items))
+	for i := range list.Items {
+		states = append(states, machineConfigPoolState(&list.Items[i]))
+	}
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].Name < states[j].Name
+	})
+	return states, nil
+}
+
+// WaitForMachineConfigPools blocks until every MachineConfigPool which is not paused moved away
+// from the rendered MachineConfig it had in previous and finished rolling out the new one,
+// or until timeout expires. Progress is logged whenever the state of a pool changes.
+func WaitForMachineConfigPools(ctx context.Context, cli client.Client, previous []MachineConfigPoolState, timeout time.Duration) error {
+	klog.InfoS("Waiting for MachineConfigPools to roll out", "timeout", timeout)
+
+	previousConfig := make(map[string]string, len(previous))
+	for _, state := range previous {
+		previousConfig[state.Name] = state.RenderedConfig
+	}
+
+	var pending []string
+	lastProgress := map[string]string{}
+
+	err := wait.PollUntilContextTimeout(ctx, machineConfigPoolPollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+		states, err := ListMachineConfigPools(ctx, cli)
+		if err != nil {
+			return false, err
+		}
+
+		pending = pending[:0]
+		for _, state := range states {
+			progress := state.String()
+			if lastProgress[state.Name] != progress {
+				klog.InfoS("MachineConfigPool progress", "pool", state.Name, "renderedConfig", state.RenderedConfig, "status", progress)
+				lastProgress[state.Name] = progress
+			}
+
+			if state.Paused {
+				continue
+			}
+			if state.Degraded {
+				return false, fmt.Errorf("MachineConfigPool %s is degraded", state.Name)
+			}
+			// The pool reports Updated until the new MachineConfig was rendered
+			if state.RenderedConfig == previousConfig[state.Name] || !state.Updated {
+				pending = append(pending, state.Name)
+			}
+		}
+		return len(pending) == 0, nil
+	})
+	if err != nil {
+		if wait.Interrupted(err) {
+			return fmt.Errorf("timed out after %s waiting for MachineConfigPools to roll out: %s", timeout, strings.Join(pending, ", "))
+		}
+		return err
+	}
+
+	klog.InfoS("MachineConfigPools rolled out")
+	return nil
+}
+
+func machineConfigPoolState(pool *unstructured.Unstructured) MachineConfigPoolState {
+	state := MachineConfigPoolState{Name: pool.GetName()}
+	state.RenderedConfig, _, _ = unstructured.NestedString(pool.Object, "spec", "configuration", "name")
+	state.Paused, _, _ = unstructured.NestedBool(pool.Object, "spec", "paused")
+	state.MachineCount, _, _ = unstructured.NestedInt64(pool.Object, "status", "machineCount")
+	state.UpdatedCount, _, _ = unstructured.NestedInt64(pool.Object, "status", "updatedMachineCount")
+
+	observed, _, _ := unstructured.NestedInt64(pool.Object, "status", "observedGeneration")
+	conditions, _, _ := unstructured.NestedSlice(pool.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]any)
+		if !ok {
+			continue
+		}
+		switch condition["type"] {
+		case "Updated":
+			state.Updated = condition["status"] == "True" && observed >= pool.GetGeneration()
+		case "Degraded":
+			state.Degraded = condition["status"] == "True"
+		}
+	}
+	return state
+}
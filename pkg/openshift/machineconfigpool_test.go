@@ -0,0 +1,67 @@
+package openshift
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newPool(generation, observed int64, conditions ...map[string]any) *unstructured.Unstructured {
+	items := make([]any, 0, len(conditions))
+	for _, c := range conditions {
+		items = append(items, c)
+	}
+	pool := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "machineconfiguration.openshift.io/v1",
+		"kind":       "MachineConfigPool",
+		"metadata":   map[string]any{"name": "worker"},
+		"spec": map[string]any{
+			"configuration": map[string]any{"name": "rendered-worker-abc"},
+		},
+		"status": map[string]any{
+			"observedGeneration":  observed,
+			"machineCount":        int64(3),
+			"updatedMachineCount": int64(1),
+			"conditions":          items,
+		},
+	}}
+	pool.SetGeneration(generation)
+	return pool
+}
+
+func TestMachineConfigPoolState(t *testing.T) {
+	updated := map[string]any{"type": "Updated", "status": "True"}
+	updating := map[string]any{"type": "Updated", "status": "False"}
+	degraded := map[string]any{"type": "Degraded", "status": "True"}
+
+	tests := []struct {
+		name     string
+		pool     *unstructured.Unstructured
+		updated  bool
+		degraded bool
+		progress string
+	}{
+		{name: "updated", pool: newPool(2, 2, updated), updated: true, progress: "updated (1/3 machines)"},
+		{name: "updating", pool: newPool(2, 2, updating), progress: "updating (1/3 machines)"},
+		{name: "generation not observed", pool: newPool(3, 2, updated), progress: "updating (1/3 machines)"},
+		{name: "degraded", pool: newPool(2, 2, updating, degraded), degraded: true, progress: "degraded"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := machineConfigPoolState(tt.pool)
+			if state.Name != "worker" || state.RenderedConfig != "rendered-worker-abc" {
+				t.Errorf("Unexpected pool identity %q/%q", state.Name, state.RenderedConfig)
+			}
+			if state.Updated != tt.updated {
+				t.Errorf("Updated = %v, want %v", state.Updated, tt.updated)
+			}
+			if state.Degraded != tt.degraded {
+				t.Errorf("Degraded = %v, want %v", state.Degraded, tt.degraded)
+			}
+			if got := state.String(); got != tt.progress {
+				t.Errorf("String() = %q, want %q", got, tt.progress)
+			}
+		})
+	}
+}
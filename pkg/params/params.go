@@ -1,12 +1,23 @@
 package params
 
-import "github.com/k8stopologyawareschedwg/deployer/pkg/deployer/platform"
+import (
+	"github.com/k8stopologyawareschedwg/deployer/pkg/deployer/platform"
+
+	"github.com/Tal-or/dra-deployer/pkg/image"
+)
 
 type EnvConfig struct {
-	Namespace    string
-	NodeSelector map[string]string // NodeSelector to be applied to the daemonset pods
-	Image        string
-	Command      string
-	Platform     platform.Platform // Platform of the cluster
-	Values       map[string]any
+	Namespace           string
+	NodeSelector        map[string]string // NodeSelector to be applied to the daemonset pods
+	Image               string
+	Command             string
+	Platform            platform.Platform // Platform of the cluster
+	Values              map[string]any
+	ReleaseName         string        // ReleaseName is the Helm release name, the chart name is used when empty
+	ImagePullSecrets    []string      // ImagePullSecrets are existing secrets used to pull the image
+	RegistryAuth        string        // RegistryAuth is a registry auth file content stored in a dockerconfigjson Secret used to pull the image
+	RegistryMirrors     image.Mirrors // RegistryMirrors rewrite the container images for disconnected clusters
+	ResourceAPIVersions []string      // ResourceAPIVersions are the served resource.k8s.io versions, preferred first, the chart defaults are used when empty
+	ChartDir            string        // ChartDir selects the embedded Helm chart, helm.DefaultChartDir is used when empty
+	ChartPath           string        // ChartPath overrides the embedded Helm chart with a chart directory on the filesystem
 }
@@ -0,0 +1,293 @@
+// Package preflight checks a cluster meets the requirements of a DRA driver installation before deploying it.
+package preflight
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/k8stopologyawareschedwg/deployer/pkg/deployer/platform"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/Tal-or/dra-deployer/pkg/helm"
+	"github.com/Tal-or/dra-deployer/pkg/openshift"
+	"github.com/Tal-or/dra-deployer/pkg/params"
+	"github.com/Tal-or/dra-deployer/pkg/resourceapi"
+)
+
+// Result is the outcome of a preflight check
+type Result string
+
+const (
+	ResultPass Result = "pass"
+	ResultWarn Result = "warn"
+	ResultFail Result = "fail"
+)
+
+// Check reports the outcome of a single preflight check
+type Check struct {
+	Name    string `json:"name"`
+	Result  Result `json:"result"`
+	Message string `json:"message"`
+}
+
+// Run checks the cluster meets the requirements of the installation described by envConfig.
+// Unmet requirements are reported as failed checks, an error is only returned when a check
+// could not be performed.
+func Run(ctx context.Context, cli client.Client, envConfig params.EnvConfig) ([]Check, error) {
+	klog.V(2).InfoS("Running preflight checks", "namespace", envConfig.Namespace, "platform", envConfig.Platform)
+
+	chartLoader, err := helm.NewChartLoaderForConfig(envConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load Helm chart: %w", err)
+	}
+
+	objects, err := chartLoader.Render(envConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render Helm chart: %w", err)
+	}
+
+	checks := []Check{
+		checkResourceAPI(cli.RESTMapper()),
+		checkValidatingAdmissionPolicy(cli.RESTMapper(), objects),
+	}
+
+	permissions, err := checkPermissions(ctx, cli, envConfig.Namespace, objects)
+	if err != nil {
+		return nil, err
+	}
+	checks = append(checks, permissions...)
+
+	if envConfig.Platform == platform.OpenShift {
+		featureGate, err := checkFeatureGate(ctx, cli)
+		if err != nil {
+			return nil, err
+		}
+		checks = append(checks, featureGate)
+	}
+
+	checks = append(checks, checkNodes(ctx, cli, objects))
+
+	return checks, nil
+}
+
+// Failed returns true if any of the checks failed
+func Failed(checks []Check) bool {
+	for _, check := range checks {
+		if check.Result == ResultFail {
+			return true
+		}
+	}
+	return false
+}
+
+// checkResourceAPI checks the resource.k8s.io API group is served, i.e. the DynamicResourceAllocation
+// feature gate is enabled
+func checkResourceAPI(mapper meta.RESTMapper) Check {
+	check := Check{Name: "resource.k8s.io API"}
+
+	versions, err := resourceapi.Discover(mapper)
+	switch {
+	case err != nil:
+		check.Result = ResultFail
+		check.Message = err.Error()
+	case len(versions) == 0:
+		check.Result = ResultFail
+		check.Message = "not served, enable the DynamicResourceAllocation feature gate and the resource.k8s.io API group"
+	default:
+		check.Result = ResultPass
+		check.Message = fmt.Sprintf("served versions: %s", strings.Join(versions, ", "))
+	}
+	return check
+}
+
+// checkValidatingAdmissionPolicy checks ValidatingAdmissionPolicy is available. It is required
+// when the chart renders a policy and recommended otherwise.
+func checkValidatingAdmissionPolicy(mapper meta.RESTMapper, objects []*unstructured.Unstructured) Check {
+	check := Check{Name: "ValidatingAdmissionPolicy"}
+
+	gk := schema.GroupKind{Group: "admissionregistration.k8s.io", Kind: "ValidatingAdmissionPolicy"}
+	if _, err := mapper.RESTMapping(gk, "v1"); err != nil {
+		check.Result = ResultWarn
+		check.Message = "admissionregistration.k8s.io/v1 is not served, ResourceSlices are not protected"
+		if !meta.IsNoMatchError(err) {
+			check.Message = err.Error()
+		}
+		if rendersKind(objects, gk) {
+			check.Result = ResultFail
+		}
+		return check
+	}
+
+	check.Result = ResultPass
+	check.Message = "available"
+	if !rendersKind(objects, gk) {
+		check.Result = ResultWarn
+		check.Message = "available, but validatingAdmissionPolicy.create is disabled"
+	}
+	return check
+}
+
+// applyVerbs are the verbs server-side apply needs: it creates missing objects and patches
+// existing ones
+var applyVerbs = []string{"create", "patch"}
+
+// checkPermissions checks the caller is allowed to create and patch every rendered kind, and
+// the install namespace when it does not exist yet
+func checkPermissions(ctx context.Context, cli client.Client, namespace string, objects []*unstructured.Unstructured) ([]Check, error) {
+	toCreate := objects
+
+	err := cli.Get(ctx, client.ObjectKey{Name: namespace}, &corev1.Namespace{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return nil, fmt.Errorf("failed to get namespace %s: %w", namespace, err)
+	}
+	if apierrors.IsNotFound(err) {
+		ns := &unstructured.Unstructured{}
+		ns.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Namespace"))
+		ns.SetName(namespace)
+		toCreate = append([]*unstructured.Unstructured{ns}, objects...)
+	}
+
+	var checks []Check
+	seen := make(map[string]bool)
+	for _, obj := range toCreate {
+		gvk := obj.GroupVersionKind()
+
+		mapping, err := cli.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			if !meta.IsNoMatchError(err) {
+				return nil, fmt.Errorf("failed to map %s: %w", gvk, err)
+			}
+			if seen[gvk.String()] {
+				continue
+			}
+			seen[gvk.String()] = true
+			checks = append(checks, Check{
+				Name:    fmt.Sprintf("create %s", gvk.Kind),
+				Result:  ResultFail,
+				Message: fmt.Sprintf("%s is not served by the cluster", gvk.GroupVersion()),
+			})
+			continue
+		}
+
+		resource := mapping.Resource.GroupResource().String()
+		if seen[resource] {
+			continue
+		}
+		seen[resource] = true
+
+		for _, verb := range applyVerbs {
+			ssar := &authorizationv1.SelfSubjectAccessReview{
+				Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+					ResourceAttributes: &authorizationv1.ResourceAttributes{
+						Namespace: obj.GetNamespace(),
+						Verb:      verb,
+						Group:     mapping.Resource.Group,
+						Resource:  mapping.Resource.Resource,
+					},
+				},
+			}
+			if err := cli.Create(ctx, ssar); err != nil {
+				return nil, fmt.Errorf("failed to review access to %s %s: %w", verb, resource, err)
+			}
+
+			check := Check{Name: fmt.Sprintf("%s %s", verb, resource)}
+			if ssar.Status.Allowed {
+				check.Result = ResultPass
+				check.Message = "allowed"
+			} else {
+				check.Result = ResultFail
+				check.Message = "forbidden"
+				if ssar.Status.Reason != "" {
+					check.Message = fmt.Sprintf("forbidden: %s", ssar.Status.Reason)
+				}
+			}
+			checks = append(checks, check)
+		}
+	}
+	return checks, nil
+}
+
+// checkFeatureGate checks the OpenShift cluster FeatureGate enables DRA
+func checkFeatureGate(ctx context.Context, cli client.Client) (Check, error) {
+	check := Check{Name: "OpenShift FeatureGate"}
+
+	fg, err := openshift.GetFeatureGate(ctx, cli)
+	if err != nil {
+		return check, err
+	}
+
+	featureSet := string(fg.Spec.FeatureSet)
+	if featureSet == "" {
+		featureSet = "Default"
+	}
+	if openshift.DRAEnabled(fg) {
+		check.Result = ResultPass
+		check.Message = fmt.Sprintf("featureSet %s enables %s", featureSet, openshift.DRAFeatureGate)
+	} else {
+		check.Result = ResultFail
+		check.Message = fmt.Sprintf("featureSet %s does not enable %s, run dra-deployer openshift enable-dra", featureSet, openshift.DRAFeatureGate)
+	}
+	return check, nil
+}
+
+// checkNodes checks some nodes match the node selector of the rendered plugin DaemonSet. A
+// selector that cannot be read or nodes that cannot be listed fail the check.
+func checkNodes(ctx context.Context, cli client.Client, objects []*unstructured.Unstructured) Check {
+	check := Check{Name: "target nodes"}
+
+	var selector map[string]string
+	for _, obj := range objects {
+		if obj.GroupVersionKind().GroupKind() != (schema.GroupKind{Group: "apps", Kind: "DaemonSet"}) {
+			continue
+		}
+		var err error
+		selector, _, err = unstructured.NestedStringMap(obj.Object, "spec", "template", "spec", "nodeSelector")
+		if err != nil {
+			check.Result = ResultFail
+			check.Message = fmt.Sprintf("failed to get node selector of DaemonSet %s: %v", obj.GetName(), err)
+			return check
+		}
+	}
+
+	nodes := &corev1.NodeList{}
+	if err := cli.List(ctx, nodes, client.MatchingLabels(selector)); err != nil {
+		check.Result = ResultFail
+		check.Message = fmt.Sprintf("failed to list nodes: %v", err)
+		return check
+	}
+
+	matching := "all nodes"
+	if len(selector) > 0 {
+		matching = fmt.Sprintf("node selector %s", labels.FormatLabels(selector))
+	}
+
+	if len(nodes.Items) == 0 {
+		check.Result = ResultFail
+		check.Message = fmt.Sprintf("no node matches %s", matching)
+		return check
+	}
+
+	check.Result = ResultPass
+	check.Message = fmt.Sprintf("%d node(s) match %s", len(nodes.Items), matching)
+	return check
+}
+
+func rendersKind(objects []*unstructured.Unstructured, gk schema.GroupKind) bool {
+	for _, obj := range objects {
+		if obj.GroupVersionKind().GroupKind() == gk {
+			return true
+		}
+	}
+	return false
+}
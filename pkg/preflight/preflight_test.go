@@ -0,0 +1,179 @@
+package preflight
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func newMapper(gvks ...schema.GroupVersionKind) meta.RESTMapper {
+	var gvs []schema.GroupVersion
+	for _, gvk := range gvks {
+		gvs = append(gvs, gvk.GroupVersion())
+	}
+	mapper := meta.NewDefaultRESTMapper(gvs)
+	for _, gvk := range gvks {
+		mapper.Add(gvk, meta.RESTScopeRoot)
+	}
+	return mapper
+}
+
+func TestCheckResourceAPI(t *testing.T) {
+	served := checkResourceAPI(newMapper(
+		schema.GroupVersionKind{Group: "resource.k8s.io", Version: "v1", Kind: "ResourceSlice"},
+		schema.GroupVersionKind{Group: "resource.k8s.io", Version: "v1beta2", Kind: "ResourceSlice"},
+	))
+	if served.Result != ResultPass {
+		t.Errorf("Expected pass when resource.k8s.io is served, got %s: %s", served.Result, served.Message)
+	}
+	if !strings.Contains(served.Message, "v1, v1beta2") {
+		t.Errorf("Expected served versions in message, got %q", served.Message)
+	}
+
+	missing := checkResourceAPI(newMapper())
+	if missing.Result != ResultFail {
+		t.Errorf("Expected fail when resource.k8s.io is not served, got %s", missing.Result)
+	}
+}
+
+func TestCheckValidatingAdmissionPolicy(t *testing.T) {
+	vapGVK := schema.GroupVersionKind{Group: "admissionregistration.k8s.io", Version: "v1", Kind: "ValidatingAdmissionPolicy"}
+	vap := &unstructured.Unstructured{}
+	vap.SetGroupVersionKind(vapGVK)
+
+	tests := []struct {
+		name    string
+		mapper  meta.RESTMapper
+		objects []*unstructured.Unstructured
+		expect  Result
+	}{
+		{name: "available and rendered", mapper: newMapper(vapGVK), objects: []*unstructured.Unstructured{vap}, expect: ResultPass},
+		{name: "available but not rendered", mapper: newMapper(vapGVK), expect: ResultWarn},
+		{name: "missing and rendered", mapper: newMapper(), objects: []*unstructured.Unstructured{vap}, expect: ResultFail},
+		{name: "missing and not rendered", mapper: newMapper(), expect: ResultWarn},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := checkValidatingAdmissionPolicy(tt.mapper, tt.objects)
+			if check.Result != tt.expect {
+				t.Errorf("Expected %s, got %s: %s", tt.expect, check.Result, check.Message)
+			}
+		})
+	}
+}
+
+func TestCheckPermissions(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+
+	clusterRoleGVK := schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole"}
+	clusterRole := &unstructured.Unstructured{}
+	clusterRole.SetGroupVersionKind(clusterRoleGVK)
+	clusterRole.SetName("dra-driver-memory-role")
+
+	// The caller may create ClusterRoles, but not patch them
+	cli := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithRESTMapper(newMapper(clusterRoleGVK)).
+		WithObjects(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "dra"}}).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+				if ssar, ok := obj.(*authorizationv1.SelfSubjectAccessReview); ok {
+					ssar.Status.Allowed = ssar.Spec.ResourceAttributes.Verb == "create"
+					return nil
+				}
+				return c.Create(ctx, obj, opts...)
+			},
+		}).
+		Build()
+
+	checks, err := checkPermissions(context.Background(), cli, "dra", []*unstructured.Unstructured{clusterRole})
+	if err != nil {
+		t.Fatalf("checkPermissions() failed: %v", err)
+	}
+
+	results := make(map[string]Result)
+	for _, check := range checks {
+		results[check.Name] = check.Result
+	}
+	expected := map[string]Result{
+		"create clusterroles.rbac.authorization.k8s.io": ResultPass,
+		"patch clusterroles.rbac.authorization.k8s.io":  ResultFail,
+	}
+	if len(results) != len(expected) {
+		t.Fatalf("Expected checks %v, got %v", expected, results)
+	}
+	for name, want := range expected {
+		if results[name] != want {
+			t.Errorf("Expected %q to %s, got %q", name, want, results[name])
+		}
+	}
+}
+
+func TestCheckNodesListFailure(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+
+	cli := fake.NewClientBuilder().WithScheme(scheme).WithInterceptorFuncs(interceptor.Funcs{
+		List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+			return errors.New("nodes is forbidden")
+		},
+	}).Build()
+
+	check := checkNodes(context.Background(), cli, nil)
+	if check.Result != ResultFail {
+		t.Errorf("Expected fail when the nodes cannot be listed, got %s", check.Result)
+	}
+	if !strings.Contains(check.Message, "nodes is forbidden") {
+		t.Errorf("Expected the list error in message, got %q", check.Message)
+	}
+}
+
+func TestFailed(t *testing.T) {
+	checks := []Check{
+		{Name: "a", Result: ResultPass},
+		{Name: "b", Result: ResultWarn},
+	}
+	if Failed(checks) {
+		t.Error("Expected warnings not to fail the preflight")
+	}
+
+	checks = append(checks, Check{Name: "c", Result: ResultFail})
+	if !Failed(checks) {
+		t.Error("Expected a failed check to fail the preflight")
+	}
+}
+
+func TestPrint(t *testing.T) {
+	var buf bytes.Buffer
+	err := Print(&buf, []Check{
+		{Name: "resource.k8s.io API", Result: ResultPass, Message: "served versions: v1"},
+		{Name: "create daemonsets.apps", Result: ResultFail, Message: "forbidden"},
+	})
+	if err != nil {
+		t.Fatalf("Print failed: %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{"CHECK", "resource.k8s.io API", "PASS", "create daemonsets.apps", "FAIL", "forbidden"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+}
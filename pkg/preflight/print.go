@@ -0,0 +1,18 @@
+package preflight
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// Print writes the checks to w as a table
+func Print(w io.Writer, checks []Check) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "CHECK\tRESULT\tMESSAGE")
+	for _, check := range checks {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", check.Name, strings.ToUpper(string(check.Result)), check.Message)
+	}
+	return tw.Flush()
+}
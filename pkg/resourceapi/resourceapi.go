@@ -0,0 +1,91 @@
+// Package resourceapi selects the resource.k8s.io API versions the DRA objects are rendered
+// and accessed with, either discovered from the cluster or derived from a Kubernetes version.
+package resourceapi
+
+import (
+	"fmt"
+	"strconv"
+
+	"helm.sh/helm/v3/pkg/chartutil"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const Group = "resource.k8s.io"
+
+// Versions are the resource.k8s.io versions of structured parameters DRA the deployer
+// supports, in order of preference
+var Versions = []string{"v1", "v1beta2", "v1beta1", "v1alpha3"}
+
+// DefaultVersions are the versions served by the newest supported Kubernetes version, used
+// when neither the cluster nor a Kubernetes version is known
+var DefaultVersions = []string{"v1", "v1beta2", "v1beta1"}
+
+// kubeVersions maps a Kubernetes minor version to the resource.k8s.io versions it serves
+// by default, preferred first
+var kubeVersions = map[uint64][]string{
+	31: {"v1alpha3"},
+	32: {"v1beta1"},
+	33: {"v1beta2", "v1beta1"},
+	34: DefaultVersions,
+}
+
+// Discover returns the supported resource.k8s.io versions served by the cluster, preferred
+// first. It returns an empty list when the cluster serves none of them.
+func Discover(mapper meta.RESTMapper) ([]string, error) {
+	mappings, err := mapper.RESTMappings(schema.GroupKind{Group: Group, Kind: "ResourceSlice"})
+	if err != nil {
+		if meta.IsNoMatchError(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to discover %s versions: %w", Group, err)
+	}
+
+	served := make(map[string]bool, len(mappings))
+	for _, mapping := range mappings {
+		served[mapping.GroupVersionKind.Version] = true
+	}
+
+	var versions []string
+	for _, version := range Versions {
+		if served[version] {
+			versions = append(versions, version)
+		}
+	}
+	return versions, nil
+}
+
+// ForKubeVersion returns the resource.k8s.io versions served by default by the given
+// Kubernetes version (e.g. "1.33" or "v1.33.2"), preferred first
+func ForKubeVersion(kubeVersion string) ([]string, error) {
+	v, err := chartutil.ParseKubeVersion(kubeVersion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Kubernetes version %q: %w", kubeVersion, err)
+	}
+
+	if v.Major != "1" {
+		return nil, fmt.Errorf("unsupported Kubernetes version %q, only 1.x versions are supported", kubeVersion)
+	}
+	kv, err := strconv.ParseUint(v.Minor, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Kubernetes version %q: %w", kubeVersion, err)
+	}
+
+	if kv < 31 {
+		return nil, fmt.Errorf("structured parameters DRA requires Kubernetes 1.31 or newer, got %s", v.Version)
+	}
+	if versions, ok := kubeVersions[kv]; ok {
+		return versions, nil
+	}
+	return DefaultVersions, nil
+}
+
+// Preferred returns the preferred version of the given list, falling back to the
+// preferred default version for an empty list
+func Preferred(versions []string) string {
+	if len(versions) == 0 {
+		return DefaultVersions[0]
+	}
+	return versions[0]
+}
@@ -0,0 +1,86 @@
+package resourceapi
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestForKubeVersion(t *testing.T) {
+	tests := []struct {
+		kubeVersion string
+		expected    []string
+		expectErr   bool
+	}{
+		{kubeVersion: "1.35", expected: []string{"v1", "v1beta2", "v1beta1"}},
+		{kubeVersion: "v1.34.2", expected: []string{"v1", "v1beta2", "v1beta1"}},
+		{kubeVersion: "1.33", expected: []string{"v1beta2", "v1beta1"}},
+		{kubeVersion: "1.32.0", expected: []string{"v1beta1"}},
+		{kubeVersion: "1.31", expected: []string{"v1alpha3"}},
+		{kubeVersion: "1.30", expectErr: true},
+		{kubeVersion: "2.0", expectErr: true},
+		{kubeVersion: "latest", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.kubeVersion, func(t *testing.T) {
+			versions, err := ForKubeVersion(tt.kubeVersion)
+			if tt.expectErr {
+				if err == nil {
+					t.Errorf("Expected error, got versions %v", versions)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(versions, tt.expected) {
+				t.Errorf("Expected versions %v, got %v", tt.expected, versions)
+			}
+		})
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	tests := []struct {
+		name     string
+		served   []string
+		expected []string
+	}{
+		{name: "preference order", served: []string{"v1beta1", "v1", "v1beta2"}, expected: []string{"v1", "v1beta2", "v1beta1"}},
+		{name: "unsupported versions are ignored", served: []string{"v1alpha2", "v1beta1"}, expected: []string{"v1beta1"}},
+		{name: "group not served", served: nil, expected: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gvs []schema.GroupVersion
+			for _, version := range tt.served {
+				gvs = append(gvs, schema.GroupVersion{Group: Group, Version: version})
+			}
+			mapper := meta.NewDefaultRESTMapper(gvs)
+			for _, gv := range gvs {
+				mapper.Add(gv.WithKind("ResourceSlice"), meta.RESTScopeRoot)
+			}
+
+			versions, err := Discover(mapper)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(versions, tt.expected) {
+				t.Errorf("Expected versions %v, got %v", tt.expected, versions)
+			}
+		})
+	}
+}
+
+func TestPreferred(t *testing.T) {
+	if got := Preferred([]string{"v1beta2", "v1beta1"}); got != "v1beta2" {
+		t.Errorf("Expected v1beta2, got %q", got)
+	}
+	if got := Preferred(nil); got != "v1" {
+		t.Errorf("Expected v1 for an empty list, got %q", got)
+	}
+}
@@ -0,0 +1,98 @@
+package status
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Output formats supported by Print
+const (
+	OutputTable = "table"
+	OutputJSON  = "json"
+	OutputYAML  = "yaml"
+)
+
+// Print writes st to w in the given output format
+func Print(w io.Writer, st *Status, format string) error {
+	switch format {
+	case OutputTable, "":
+		return printTable(w, st)
+	case OutputJSON:
+		data, err := json.MarshalIndent(st, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal status to JSON: %w", err)
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	case OutputYAML:
+		data, err := yaml.Marshal(st)
+		if err != nil {
+			return fmt.Errorf("failed to marshal status to YAML: %w", err)
+		}
+		_, err = w.Write(data)
+		return err
+	default:
+		return fmt.Errorf("unsupported output format %q, must be one of: %s, %s, %s", format, OutputTable, OutputJSON, OutputYAML)
+	}
+}
+
+func printTable(w io.Writer, st *Status) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "NAMESPACE:\t%s\n", st.Namespace)
+	fmt.Fprintf(tw, "DRIVER:\t%s\n", st.Driver)
+
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "KIND\tNAMESPACE\tNAME\tEXISTS\tUP-TO-DATE")
+	for _, obj := range st.Objects {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%t\t%t\n", obj.Kind, orNone(obj.Namespace), obj.Name, obj.Exists, obj.UpToDate)
+	}
+
+	fmt.Fprintln(tw)
+	if st.DaemonSet == nil {
+		fmt.Fprintln(tw, "DAEMONSET:\t<not found>")
+	} else {
+		ds := st.DaemonSet
+		fmt.Fprintln(tw, "DAEMONSET\tDESIRED\tCURRENT\tUP-TO-DATE\tREADY\tAVAILABLE\tROLLED-OUT")
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%d\t%t\n", ds.Name, ds.Desired, ds.Current, ds.Updated, ds.Ready, ds.Available, ds.RolledOut)
+	}
+
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "NODE\tPOD\tPHASE\tREADY\tRESTARTS\tIMAGE")
+	for _, pod := range st.Pods {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%t\t%d\t%s\n", pod.Node, pod.Name, pod.Phase, pod.Ready, pod.Restarts, pod.Image)
+	}
+
+	fmt.Fprintln(tw)
+	if st.ResourceAPIUnavailable {
+		fmt.Fprintln(tw, "RESOURCESLICES:\t<resource.k8s.io not served by the cluster>")
+	} else {
+		fmt.Fprintln(tw, "NODE\tRESOURCESLICE\tPOOL\tDEVICES")
+		for _, slice := range st.ResourceSlices {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%d\n", orNone(slice.Node), slice.Name, slice.Pool, slice.Devices)
+		}
+	}
+
+	fmt.Fprintln(tw)
+	if st.Signature == nil {
+		fmt.Fprintln(tw, "SIGNATURE:\t<not verified>")
+	} else {
+		sig := st.Signature
+		fmt.Fprintln(tw, "VERIFIED IMAGE\tPOLICY\tVERIFIED-AT\tCURRENT")
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%t\n", sig.Image, sig.Policy, sig.VerifiedAt.UTC().Format(time.RFC3339), sig.Current)
+	}
+
+	return tw.Flush()
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "<none>"
+	}
+	return s
+}
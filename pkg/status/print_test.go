@@ -0,0 +1,109 @@
+package status
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func testStatus() *Status {
+	return &Status{
+		Namespace: "dra-deployer",
+		Driver:    "manager.memory.com",
+		Objects: []ObjectStatus{
+			{Kind: "ClusterRole", Name: "dra-driver-memory-role", Exists: true, UpToDate: true},
+			{Kind: "DaemonSet", Namespace: "dra-deployer", Name: "dra-driver-memory-kubeletplugin", Exists: true, UpToDate: false},
+		},
+		DaemonSet: &DaemonSetStatus{
+			Name:      "dra-driver-memory-kubeletplugin",
+			Desired:   2,
+			Current:   2,
+			Updated:   1,
+			Ready:     1,
+			Available: 1,
+		},
+		Pods: []PodStatus{
+			{Node: "node-a", Name: "plugin-a", Phase: "Running", Ready: true, Restarts: 0, Image: "quay.io/org/driver:v1"},
+			{Node: "node-b", Name: "plugin-b", Phase: "Pending", Ready: false, Restarts: 3, Image: "quay.io/org/driver:v2"},
+		},
+		ResourceSlices: []ResourceSliceStatus{
+			{Node: "node-a", Name: "node-a-manager.memory.com-x7k2p", Pool: "node-a", Devices: 8},
+		},
+	}
+}
+
+func TestPrintTable(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Print(&buf, testStatus(), OutputTable); err != nil {
+		t.Fatalf("Print() failed: %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		"manager.memory.com",
+		"ClusterRole",
+		"<none>",
+		"dra-driver-memory-kubeletplugin",
+		"plugin-b",
+		"quay.io/org/driver:v2",
+		"node-a-manager.memory.com-x7k2p",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("table output does not contain %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestPrintJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Print(&buf, testStatus(), OutputJSON); err != nil {
+		t.Fatalf("Print() failed: %v", err)
+	}
+
+	got := &Status{}
+	if err := json.Unmarshal(buf.Bytes(), got); err != nil {
+		t.Fatalf("Failed to unmarshal JSON output: %v", err)
+	}
+	if len(got.Pods) != 2 || got.Pods[1].Restarts != 3 {
+		t.Errorf("unexpected pods in JSON output: %+v", got.Pods)
+	}
+}
+
+func TestPrintUnsupportedFormat(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Print(&buf, testStatus(), "wide"); err == nil {
+		t.Error("Print() expected error for unsupported format, got nil")
+	}
+}
+
+func TestPrintTableSignature(t *testing.T) {
+	st := testStatus()
+
+	var buf bytes.Buffer
+	if err := Print(&buf, st, OutputTable); err != nil {
+		t.Fatalf("Print() failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "<not verified>") {
+		t.Errorf("table output does not report the missing verification:\n%s", buf.String())
+	}
+
+	st.Signature = &SignatureStatus{
+		Image:      "quay.io/org/driver:v1@sha256:2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae",
+		Policy:     "/etc/containers/policy.json",
+		VerifiedAt: metav1.NewTime(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)),
+		Current:    true,
+	}
+	buf.Reset()
+	if err := Print(&buf, st, OutputTable); err != nil {
+		t.Fatalf("Print() failed: %v", err)
+	}
+	for _, want := range []string{st.Signature.Image, "/etc/containers/policy.json", "2026-01-02T03:04:05Z"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("table output does not contain %q:\n%s", want, buf.String())
+		}
+	}
+}
@@ -0,0 +1,275 @@
+package status
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/Tal-or/dra-deployer/pkg/deploy"
+	"github.com/Tal-or/dra-deployer/pkg/helm"
+	"github.com/Tal-or/dra-deployer/pkg/inventory"
+	"github.com/Tal-or/dra-deployer/pkg/params"
+	"github.com/Tal-or/dra-deployer/pkg/resourceapi"
+)
+
+// Status summarizes a DRA driver installation
+type Status struct {
+	Namespace      string                `json:"namespace"`
+	Driver         string                `json:"driver"`
+	Objects        []ObjectStatus        `json:"objects"`
+	DaemonSet      *DaemonSetStatus      `json:"daemonSet,omitempty"`
+	Pods           []PodStatus           `json:"pods"`
+	ResourceSlices []ResourceSliceStatus `json:"resourceSlices"`
+	Signature      *SignatureStatus      `json:"signature,omitempty"`
+	// ResourceAPIUnavailable is true if the cluster does not serve resource.k8s.io, so no DeviceClass
+	// nor ResourceSlice can exist
+	ResourceAPIUnavailable bool `json:"resourceAPIUnavailable,omitempty"`
+}
+
+// ObjectStatus reports whether a rendered object exists in the cluster and matches the rendered state
+type ObjectStatus struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	Exists    bool   `json:"exists"`
+	UpToDate  bool   `json:"upToDate"`
+}
+
+// DaemonSetStatus reports the rollout state of the kubelet plugin DaemonSet
+type DaemonSetStatus struct {
+	Name      string `json:"name"`
+	Desired   int32  `json:"desired"`
+	Current   int32  `json:"current"`
+	Updated   int32  `json:"updated"`
+	Ready     int32  `json:"ready"`
+	Available int32  `json:"available"`
+	RolledOut bool   `json:"rolledOut"`
+}
+
+// PodStatus reports the state of the kubelet plugin pod running on a node
+type PodStatus struct {
+	Node     string `json:"node"`
+	Name     string `json:"name"`
+	Phase    string `json:"phase"`
+	Ready    bool   `json:"ready"`
+	Restarts int32  `json:"restarts"`
+	Image    string `json:"image"`
+	ImageID  string `json:"imageID,omitempty"`
+}
+
+// ResourceSliceStatus reports a ResourceSlice published by the driver
+type ResourceSliceStatus struct {
+	Node    string `json:"node"`
+	Name    string `json:"name"`
+	Pool    string `json:"pool"`
+	Devices int    `json:"devices"`
+}
+
+// SignatureStatus reports the image signature verification recorded by the last apply
+type SignatureStatus struct {
+	Image      string      `json:"image"`
+	Policy     string      `json:"policy"`
+	VerifiedAt metav1.Time `json:"verifiedAt"`
+	Current    bool        `json:"current"` // Current is true if the DaemonSet still runs the verified image
+}
+
+// Get collects the status of the DRA driver installation described by envConfig
+func Get(ctx context.Context, cli client.Client, envConfig params.EnvConfig) (*Status, error) {
+	klog.V(2).InfoS("Collecting installation status", "namespace", envConfig.Namespace)
+
+	chartLoader, err := helm.NewChartLoaderForConfig(envConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load Helm chart: %w", err)
+	}
+
+	objects, err := chartLoader.Render(envConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render Helm chart: %w", err)
+	}
+
+	driverName, err := chartLoader.DriverName(envConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	releaseName, err := chartLoader.ReleaseName(envConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	st := &Status{
+		Namespace: envConfig.Namespace,
+		Driver:    driverName,
+	}
+
+	var daemonSet *appsv1.DaemonSet
+	for _, obj := range objects {
+		objStatus := ObjectStatus{
+			Kind:      obj.GetKind(),
+			Namespace: obj.GetNamespace(),
+			Name:      obj.GetName(),
+		}
+
+		live, err := deploy.GetLive(ctx, cli, obj)
+		if err != nil {
+			// e.g. a DeviceClass on a cluster not serving resource.k8s.io
+			if !meta.IsNoMatchError(err) {
+				return nil, err
+			}
+			klog.V(2).InfoS("Object kind not served by the cluster", "kind", obj.GetKind(), "name", obj.GetName())
+		}
+		if live != nil {
+			objStatus.Exists = true
+			objStatus.UpToDate, err = deploy.IsUpToDate(ctx, cli, obj, live)
+			if err != nil {
+				return nil, err
+			}
+		}
+		st.Objects = append(st.Objects, objStatus)
+
+		if obj.GetKind() != "DaemonSet" || live == nil {
+			continue
+		}
+
+		ds := &appsv1.DaemonSet{}
+		if err := cli.Get(ctx, client.ObjectKeyFromObject(obj), ds); err != nil {
+			return nil, fmt.Errorf("failed to get DaemonSet %s: %w", obj.GetName(), err)
+		}
+		st.DaemonSet = daemonSetStatus(ds)
+		daemonSet = ds
+
+		pods, err := podStatuses(ctx, cli, ds)
+		if err != nil {
+			return nil, err
+		}
+		st.Pods = append(st.Pods, pods...)
+	}
+
+	var served bool
+	st.ResourceSlices, served, err = resourceSliceStatuses(ctx, cli, driverName, envConfig.ResourceAPIVersions)
+	if err != nil {
+		return nil, err
+	}
+	st.ResourceAPIUnavailable = !served
+
+	meta, err := inventory.LoadMetadata(ctx, cli, envConfig.Namespace, releaseName)
+	if err != nil {
+		return nil, err
+	}
+	st.Signature = signatureStatus(meta.Signature, daemonSet)
+
+	return st, nil
+}
+
+// signatureStatus reports sig, comparing the verified image, or the mirror it is pulled from, with the images of ds
+func signatureStatus(sig *inventory.Signature, ds *appsv1.DaemonSet) *SignatureStatus {
+	if sig == nil {
+		return nil
+	}
+
+	st := &SignatureStatus{
+		Image:      sig.Image,
+		Policy:     sig.Policy,
+		VerifiedAt: sig.VerifiedAt,
+	}
+	if ds != nil {
+		for _, container := range ds.Spec.Template.Spec.Containers {
+			if container.Image == sig.Image || (sig.Mirror != "" && container.Image == sig.Mirror) {
+				st.Current = true
+			}
+		}
+	}
+	return st
+}
+
+func daemonSetStatus(ds *appsv1.DaemonSet) *DaemonSetStatus {
+	return &DaemonSetStatus{
+		Name:      ds.Name,
+		Desired:   ds.Status.DesiredNumberScheduled,
+		Current:   ds.Status.CurrentNumberScheduled,
+		Updated:   ds.Status.UpdatedNumberScheduled,
+		Ready:     ds.Status.NumberReady,
+		Available: ds.Status.NumberAvailable,
+		RolledOut: deploy.DaemonSetRolledOut(ds),
+	}
+}
+
+func podStatuses(ctx context.Context, cli client.Client, ds *appsv1.DaemonSet) ([]PodStatus, error) {
+	pods, err := deploy.ListDaemonSetPods(ctx, cli, ds)
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []PodStatus
+	for _, pod := range pods {
+		podStatus := PodStatus{
+			Node:  pod.Spec.NodeName,
+			Name:  pod.Name,
+			Phase: string(pod.Status.Phase),
+			Ready: deploy.IsPodReady(&pod),
+		}
+		for _, cs := range pod.Status.ContainerStatuses {
+			podStatus.Restarts += cs.RestartCount
+			// Report the image the first container is actually running, which may differ from the spec
+			if podStatus.Image == "" {
+				podStatus.Image = cs.Image
+				podStatus.ImageID = cs.ImageID
+			}
+		}
+		if podStatus.Image == "" && len(pod.Spec.Containers) > 0 {
+			podStatus.Image = pod.Spec.Containers[0].Image
+		}
+		statuses = append(statuses, podStatus)
+	}
+	return statuses, nil
+}
+
+// resourceSliceStatuses reports the ResourceSlices published by driverName. The returned bool is false
+// if the cluster does not serve resource.k8s.io, which has no ResourceSlices then.
+func resourceSliceStatuses(ctx context.Context, cli client.Client, driverName string, versions []string) ([]ResourceSliceStatus, bool, error) {
+	// ResourceSlices are listed as unstructured objects, so any served resource.k8s.io version can be used
+	sliceList := &unstructured.UnstructuredList{}
+	sliceList.SetGroupVersionKind(schema.GroupVersionKind{
+		Group:   resourceapi.Group,
+		Version: resourceapi.Preferred(versions),
+		Kind:    "ResourceSliceList",
+	})
+	err := cli.List(ctx, sliceList, client.MatchingFields{"spec.driver": driverName})
+	if err != nil {
+		if meta.IsNoMatchError(err) {
+			klog.V(2).InfoS("The cluster does not serve resource.k8s.io", "version", sliceList.GroupVersionKind().Version)
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("failed to list ResourceSlices: %w", err)
+	}
+
+	var slices []ResourceSliceStatus
+	for _, slice := range sliceList.Items {
+		node, _, _ := unstructured.NestedString(slice.Object, "spec", "nodeName")
+		pool, _, _ := unstructured.NestedString(slice.Object, "spec", "pool", "name")
+		devices, _, _ := unstructured.NestedSlice(slice.Object, "spec", "devices")
+		slices = append(slices, ResourceSliceStatus{
+			Node:    node,
+			Name:    slice.GetName(),
+			Pool:    pool,
+			Devices: len(devices),
+		})
+	}
+
+	sort.Slice(slices, func(i, j int) bool {
+		if slices[i].Node != slices[j].Node {
+			return slices[i].Node < slices[j].Node
+		}
+		return slices[i].Name < slices[j].Name
+	})
+	return slices, true, nil
+}
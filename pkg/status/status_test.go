@@ -0,0 +1,96 @@
+package status
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	"github.com/Tal-or/dra-deployer/pkg/inventory"
+	"github.com/Tal-or/dra-deployer/pkg/params"
+)
+
+func TestSignatureStatus(t *testing.T) {
+	const verified = "quay.io/org/driver:v1@sha256:2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"
+	newDaemonSet := func(image string) *appsv1.DaemonSet {
+		ds := &appsv1.DaemonSet{}
+		ds.Spec.Template.Spec.Containers = []corev1.Container{{Name: "plugin", Image: image}}
+		return ds
+	}
+	sig := &inventory.Signature{Image: verified, Policy: "policy.json"}
+
+	if got := signatureStatus(nil, newDaemonSet(verified)); got != nil {
+		t.Errorf("Expected no signature status without verification, got %+v", got)
+	}
+	if got := signatureStatus(sig, newDaemonSet(verified)); got == nil || !got.Current {
+		t.Errorf("Expected the verified image to be current, got %+v", got)
+	}
+	if got := signatureStatus(sig, newDaemonSet("quay.io/org/driver:v2")); got == nil || got.Current {
+		t.Errorf("Expected the verified image not to be current, got %+v", got)
+	}
+
+	// The nodes pull the verified image from its mirror
+	mirrored := &inventory.Signature{Image: verified, Mirror: "mirror.example.com/quay/org/driver:v1@sha256:2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae", Policy: "policy.json"}
+	if got := signatureStatus(mirrored, newDaemonSet(mirrored.Mirror)); got == nil || !got.Current {
+		t.Errorf("Expected the mirrored verified image to be current, got %+v", got)
+	}
+	if got := signatureStatus(sig, nil); got == nil || got.Current {
+		t.Errorf("Expected the verified image not to be current without DaemonSet, got %+v", got)
+	}
+}
+
+func TestGetWithoutResourceAPI(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+
+	// A cluster not serving resource.k8s.io
+	noMatch := func(gvk schema.GroupVersionKind) error {
+		return &meta.NoKindMatchError{GroupKind: gvk.GroupKind(), SearchedVersions: []string{gvk.Version}}
+	}
+	cli := fake.NewClientBuilder().WithScheme(scheme).WithInterceptorFuncs(interceptor.Funcs{
+		Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+			if gvk := obj.GetObjectKind().GroupVersionKind(); gvk.Group == "resource.k8s.io" {
+				return noMatch(gvk)
+			}
+			return c.Get(ctx, key, obj, opts...)
+		},
+		List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+			if gvk := list.GetObjectKind().GroupVersionKind(); gvk.Group == "resource.k8s.io" {
+				return noMatch(gvk)
+			}
+			return c.List(ctx, list, opts...)
+		},
+	}).Build()
+
+	st, err := Get(context.Background(), cli, params.EnvConfig{Namespace: "dra"})
+	if err != nil {
+		t.Fatalf("Get() failed: %v", err)
+	}
+	if !st.ResourceAPIUnavailable {
+		t.Error("Expected the resource API to be reported unavailable")
+	}
+	for _, obj := range st.Objects {
+		if obj.Kind == "DeviceClass" && obj.Exists {
+			t.Errorf("DeviceClass %s reported to exist", obj.Name)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := Print(&buf, st, OutputTable); err != nil {
+		t.Fatalf("Print() failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "resource.k8s.io not served") {
+		t.Errorf("table output does not report the resource API unavailable:\n%s", buf.String())
+	}
+}
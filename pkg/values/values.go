@@ -0,0 +1,95 @@
+package values
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/strvals"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Options captures the Helm-style ways of overriding chart values from the command line
+type Options struct {
+	ValueFiles   []string // -f/--values
+	Values       []string // --set
+	StringValues []string // --set-string
+	FileValues   []string // --set-file
+}
+
+// MergeValues merges the values from files given via -f/--values with the ones
+// given directly via --set, --set-string and --set-file, in that order, like Helm does
+func (o *Options) MergeValues() (map[string]any, error) {
+	base := map[string]any{}
+
+	// Values files, later files take precedence
+	for _, filePath := range o.ValueFiles {
+		data, err := readFile(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read values file %s: %w", filePath, err)
+		}
+
+		current := map[string]any{}
+		if err := yaml.Unmarshal(data, &current); err != nil {
+			return nil, fmt.Errorf("failed to parse values file %s: %w", filePath, err)
+		}
+		base = mergeMaps(base, current)
+	}
+
+	for _, value := range o.Values {
+		if err := strvals.ParseInto(value, base); err != nil {
+			return nil, fmt.Errorf("failed parsing --set data: %w", err)
+		}
+	}
+
+	for _, value := range o.StringValues {
+		if err := strvals.ParseIntoString(value, base); err != nil {
+			return nil, fmt.Errorf("failed parsing --set-string data: %w", err)
+		}
+	}
+
+	for _, value := range o.FileValues {
+		reader := func(rs []rune) (any, error) {
+			data, err := readFile(string(rs))
+			if err != nil {
+				return nil, err
+			}
+			return string(data), nil
+		}
+		if err := strvals.ParseIntoFile(value, base, reader); err != nil {
+			return nil, fmt.Errorf("failed parsing --set-file data: %w", err)
+		}
+	}
+
+	return base, nil
+}
+
+// mergeMaps deep merges b into a copy of a, values from b take precedence
+func mergeMaps(a, b map[string]any) map[string]any {
+	out := make(map[string]any, len(a))
+	for k, v := range a {
+		out[k] = v
+	}
+	for k, v := range b {
+		if v, ok := v.(map[string]any); ok {
+			if bv, ok := out[k]; ok {
+				if bv, ok := bv.(map[string]any); ok {
+					out[k] = mergeMaps(bv, v)
+					continue
+				}
+			}
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// readFile reads a file from the local filesystem, or from stdin when filePath is "-"
+func readFile(filePath string) ([]byte, error) {
+	if strings.TrimSpace(filePath) == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(filePath)
+}
@@ -0,0 +1,160 @@
+package values
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestMergeValues(t *testing.T) {
+	dir := t.TempDir()
+
+	first := filepath.Join(dir, "first.yaml")
+	writeFile(t, first, `
+image:
+  repository: quay.io/org/first
+  pullPolicy: IfNotPresent
+daemonset:
+  env:
+    numDevices: "4"
+`)
+
+	second := filepath.Join(dir, "second.yaml")
+	writeFile(t, second, `
+image:
+  repository: quay.io/org/second
+validatingAdmissionPolicy:
+  failurePolicy: Ignore
+`)
+
+	sccName := filepath.Join(dir, "scc-name.txt")
+	writeFile(t, sccName, "my-scc")
+
+	tests := []struct {
+		name string
+		opts Options
+		want map[string]any
+	}{
+		{
+			name: "no values",
+			opts: Options{},
+			want: map[string]any{},
+		},
+		{
+			name: "later values files take precedence",
+			opts: Options{
+				ValueFiles: []string{first, second},
+			},
+			want: map[string]any{
+				"image": map[string]any{
+					"repository": "quay.io/org/second",
+					"pullPolicy": "IfNotPresent",
+				},
+				"daemonset": map[string]any{
+					"env": map[string]any{
+						"numDevices": "4",
+					},
+				},
+				"validatingAdmissionPolicy": map[string]any{
+					"failurePolicy": "Ignore",
+				},
+			},
+		},
+		{
+			name: "set overrides values files",
+			opts: Options{
+				ValueFiles: []string{first},
+				Values:     []string{"image.pullPolicy=Always", "openshift.enabled=true"},
+			},
+			want: map[string]any{
+				"image": map[string]any{
+					"repository": "quay.io/org/first",
+					"pullPolicy": "Always",
+				},
+				"daemonset": map[string]any{
+					"env": map[string]any{
+						"numDevices": "4",
+					},
+				},
+				"openshift": map[string]any{
+					"enabled": true,
+				},
+			},
+		},
+		{
+			name: "set-string overrides set",
+			opts: Options{
+				Values:       []string{"daemonset.env.numDevices=16"},
+				StringValues: []string{"daemonset.env.numDevices=32"},
+			},
+			want: map[string]any{
+				"daemonset": map[string]any{
+					"env": map[string]any{
+						"numDevices": "32",
+					},
+				},
+			},
+		},
+		{
+			name: "set-file reads the value from a file",
+			opts: Options{
+				FileValues: []string{"openshift.scc.name=" + sccName},
+			},
+			want: map[string]any{
+				"openshift": map[string]any{
+					"scc": map[string]any{
+						"name": "my-scc",
+					},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.opts.MergeValues()
+			if err != nil {
+				t.Fatalf("MergeValues() failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MergeValues() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeValuesErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{
+			name: "missing values file",
+			opts: Options{ValueFiles: []string{filepath.Join(t.TempDir(), "missing.yaml")}},
+		},
+		{
+			name: "malformed set",
+			opts: Options{Values: []string{"image.tag"}},
+		},
+		{
+			name: "missing set-file",
+			opts: Options{FileValues: []string{"openshift.scc.name=" + filepath.Join(t.TempDir(), "missing.txt")}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.opts.MergeValues(); err == nil {
+				t.Error("MergeValues() expected error, got nil")
+			}
+		})
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
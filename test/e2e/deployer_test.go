@@ -59,8 +59,8 @@ var _ = Describe("DRA Deployer E2E", func() {
 	// JustBeforeEach: Deployment execution
 	JustBeforeEach(func() {
 		By("Deploying the DRA memory plugin")
-		cmd := exec.Command(deployerBin, "apply", "-i", image, "--command", command)
-		cmd.Dir = projectRoot // Set working directory to project root
+		cmd := exec.Command(deployerBin, "apply", "-i", image, "--command", command, "--wait")
+		cmd.Dir = GinkgoT().TempDir() // The chart is embedded, so the binary must work from any directory
 		deployOutput, deployError = cmd.CombinedOutput()
 	})
 